@@ -0,0 +1,61 @@
+// Команда admin назначает роль пользователю по логину, например первому
+// администратору нового развёртывания:
+//
+//	go run ./cmd/admin -login alice -role admin
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/DENFNC/web-test/config"
+	"github.com/DENFNC/web-test/internal/domain"
+	"github.com/DENFNC/web-test/internal/infra/psql"
+	"github.com/DENFNC/web-test/internal/infra/psql/repository"
+	"github.com/DENFNC/web-test/internal/service"
+)
+
+func main() {
+	login := flag.String("login", "", "user login")
+	role := flag.String("role", domain.RoleAdmin, "role: admin, user, read-only or service")
+	envPath := flag.String("env", "./.env.example", "path to the .env file")
+	flag.Parse()
+
+	if *login == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{}))
+	cfg := config.LoadConfig(logger, *envPath)
+
+	if err := grantRole(logger, cfg, *login, *role); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Printf("%s is now %s\n", *login, *role)
+}
+
+func grantRole(log *slog.Logger, cfg *config.Config, login, role string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	db, err := psql.NewDatabase(ctx, cfg.DBConfig.URL, psql.WithMaxConns(1))
+	if err != nil {
+		return fmt.Errorf("connect to database: %w", err)
+	}
+	defer db.Pool.Close()
+
+	authRepo := repository.NewAuthRepository(log, db.Pool)
+	userID, err := authRepo.GetUserIDByLogin(ctx, login)
+	if err != nil {
+		return fmt.Errorf("find user %q: %w", login, err)
+	}
+
+	authService := service.NewAuthService(log, authRepo, domain.TokenTTL{})
+	return authService.SetUserRole(ctx, userID, role)
+}
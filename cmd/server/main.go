@@ -26,6 +26,8 @@ func main() {
 		"Calling program termination",
 		slog.String("signal", sig.String()),
 	)
+
+	application.Stop()
 }
 
 func initLogger() *slog.Logger {
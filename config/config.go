@@ -10,8 +10,22 @@ import (
 )
 
 type Config struct {
-	DBConfig  *DatabaseConfig `env:",init"`
-	AppConfig *AppConfig      `env:",init"`
+	DBConfig      *DatabaseConfig `env:",init"`
+	AppConfig     *AppConfig      `env:",init"`
+	StorageConfig *StorageConfig  `env:",init"`
+	QuotaConfig   *QuotaConfig    `env:",init"`
+	ScanConfig    *ScanConfig     `env:",init"`
+	ExpiryConfig  *ExpiryConfig   `env:",init"`
+	AdminConfig   *AdminConfig    `env:",init"`
+	WebhookConfig *WebhookConfig  `env:",init"`
+	EventsConfig  *EventsConfig   `env:",init"`
+	LockConfig    *LockConfig     `env:",init"`
+	Cache         *Cache          `env:",init"`
+	AuthConfig    *AuthConfig     `env:",init"`
+	ResetConfig   *ResetConfig    `env:",init"`
+	Notifier      *Notifier       `env:",init"`
+	LoginThrottle *LoginThrottle  `env:",init"`
+	Registration  *Registration   `env:",init"`
 }
 
 type AppConfig struct {
@@ -27,7 +41,149 @@ type DatabaseConfig struct {
 	HealthCheckPeriod time.Duration `env:"DATABASE_HEALTH_CHECK_PERIOD" envDefault:"1m"`
 }
 
-type Cache struct{}
+// StorageConfig описывает хранилище блобов. Если EncryptionKeys не пуст,
+// блобы шифруются; ключи задаются парами id:base64 через запятую, новые блобы
+// шифруются ключом EncryptionActiveKey.
+type StorageConfig struct {
+	UploadDir           string            `env:"STORAGE_UPLOAD_DIR" envDefault:"data/uploads"`
+	EncryptionKeys      map[string]string `env:"STORAGE_ENCRYPTION_KEYS"`
+	EncryptionActiveKey string            `env:"STORAGE_ENCRYPTION_ACTIVE_KEY"`
+	// MaxUploadBytes ограничивает тело запроса загрузки документа.
+	MaxUploadBytes int64 `env:"STORAGE_MAX_UPLOAD_BYTES" envDefault:"1073741824"`
+}
+
+// QuotaConfig задаёт лимиты по умолчанию; персональные лимиты хранятся в
+// user_usage. Значение 0 снимает ограничение.
+type QuotaConfig struct {
+	DefaultBytes     int64 `env:"QUOTA_DEFAULT_BYTES" envDefault:"1073741824"`
+	DefaultDocuments int64 `env:"QUOTA_DEFAULT_DOCUMENTS" envDefault:"1000"`
+}
+
+// ScanConfig настраивает проверку загрузок через clamd. Пустой адрес
+// отключает проверку.
+type ScanConfig struct {
+	ClamdAddr  string        `env:"SCAN_CLAMD_ADDR"`
+	Timeout    time.Duration `env:"SCAN_TIMEOUT" envDefault:"30s"`
+	FailClosed bool          `env:"SCAN_FAIL_CLOSED" envDefault:"false"`
+}
+
+type ExpiryConfig struct {
+	SweepInterval  time.Duration `env:"EXPIRY_SWEEP_INTERVAL" envDefault:"1m"`
+	SweepBatchSize uint          `env:"EXPIRY_SWEEP_BATCH_SIZE" envDefault:"100"`
+}
+
+type WebhookConfig struct {
+	PollInterval time.Duration `env:"WEBHOOK_POLL_INTERVAL" envDefault:"5s"`
+	BatchSize    uint          `env:"WEBHOOK_BATCH_SIZE" envDefault:"50"`
+	MaxAttempts  int32         `env:"WEBHOOK_MAX_ATTEMPTS" envDefault:"10"`
+	BaseBackoff  time.Duration `env:"WEBHOOK_BASE_BACKOFF" envDefault:"30s"`
+	MaxBackoff   time.Duration `env:"WEBHOOK_MAX_BACKOFF" envDefault:"6h"`
+	Timeout      time.Duration `env:"WEBHOOK_TIMEOUT" envDefault:"10s"`
+
+	// Доставка на loopback, частные и link-local адреса запрещена, пока
+	// AllowPrivateNetworks не включён (только для разработки).
+	AllowPrivateNetworks bool `env:"WEBHOOK_ALLOW_PRIVATE_NETWORKS" envDefault:"false"`
+}
+
+type EventsConfig struct {
+	KeepAlive      time.Duration `env:"EVENTS_KEEP_ALIVE" envDefault:"15s"`
+	Retention      time.Duration `env:"EVENTS_RETENTION" envDefault:"24h"`
+	PruneInterval  time.Duration `env:"EVENTS_PRUNE_INTERVAL" envDefault:"10m"`
+	PruneBatchSize uint          `env:"EVENTS_PRUNE_BATCH_SIZE" envDefault:"1000"`
+}
+
+type LockConfig struct {
+	DefaultTTL time.Duration `env:"LOCK_DEFAULT_TTL" envDefault:"15m"`
+	MaxTTL     time.Duration `env:"LOCK_MAX_TTL" envDefault:"2h"`
+}
+
+// AuthConfig задаёт время жизни токенов и периодичность удаления
+// просроченных.
+type AuthConfig struct {
+	AccessTokenTTL      time.Duration `env:"AUTH_ACCESS_TOKEN_TTL" envDefault:"15m"`
+	RefreshTokenTTL     time.Duration `env:"AUTH_REFRESH_TOKEN_TTL" envDefault:"720h"`
+	TokenSweepInterval  time.Duration `env:"AUTH_TOKEN_SWEEP_INTERVAL" envDefault:"1h"`
+	TokenSweepBatchSize uint          `env:"AUTH_TOKEN_SWEEP_BATCH_SIZE" envDefault:"1000"`
+
+	// После LegacyTokenSunset токен принимается только в заголовке
+	// Authorization; нулевое значение оставляет прежние способы без срока.
+	LegacyTokenSunset time.Time `env:"AUTH_LEGACY_TOKEN_SUNSET"`
+
+	// Если JWTKeys не пуст (пары kid:путь к PEM через запятую), access-токены
+	// выдаются в виде JWT, подписанных ключом JWTActiveKey.
+	JWTKeys      map[string]string `env:"AUTH_JWT_KEYS"`
+	JWTActiveKey string            `env:"AUTH_JWT_ACTIVE_KEY"`
+	JWTIssuer    string            `env:"AUTH_JWT_ISSUER" envDefault:"web-srv"`
+	JWTAudience  string            `env:"AUTH_JWT_AUDIENCE"`
+
+	// TOTPIssuer — название сервиса в приложении-аутентификаторе.
+	TOTPIssuer string `env:"AUTH_TOTP_ISSUER" envDefault:"web-srv"`
+}
+
+type ResetConfig struct {
+	CodeTTL     time.Duration `env:"PASSWORD_RESET_CODE_TTL" envDefault:"15m"`
+	MaxAttempts int32         `env:"PASSWORD_RESET_MAX_ATTEMPTS" envDefault:"5"`
+}
+
+// Notifier выбирает способ доставки сообщений пользователям: smtp или log
+// (сообщения только пишутся в журнал, для разработки).
+type Notifier struct {
+	Driver       string        `env:"NOTIFIER_DRIVER" envDefault:"log"`
+	SMTPAddr     string        `env:"SMTP_ADDR"`
+	SMTPFrom     string        `env:"SMTP_FROM"`
+	SMTPUsername string        `env:"SMTP_USERNAME"`
+	SMTPPassword string        `env:"SMTP_PASSWORD"`
+	SMTPTimeout  time.Duration `env:"SMTP_TIMEOUT" envDefault:"10s"`
+}
+
+// LoginThrottle ограничивает подбор паролей. Store — где хранятся счётчики
+// неудачных входов: postgres, cache (хранилище CACHE_DRIVER) или none.
+type LoginThrottle struct {
+	Store            string        `env:"LOGIN_THROTTLE_STORE" envDefault:"postgres"`
+	MaxLoginFailures int32         `env:"LOGIN_MAX_FAILURES_PER_LOGIN" envDefault:"5"`
+	MaxIPFailures    int32         `env:"LOGIN_MAX_FAILURES_PER_IP" envDefault:"50"`
+	FailureWindow    time.Duration `env:"LOGIN_FAILURE_WINDOW" envDefault:"15m"`
+	LockoutDuration  time.Duration `env:"LOGIN_LOCKOUT_DURATION" envDefault:"15m"`
+	BaseDelay        time.Duration `env:"LOGIN_DELAY_BASE" envDefault:"1s"`
+	MaxDelay         time.Duration `env:"LOGIN_DELAY_MAX" envDefault:"30s"`
+
+	// Сколько паролей хэшируется одновременно; 0 — по числу процессоров.
+	HashConcurrency int           `env:"PASSWORD_HASH_CONCURRENCY" envDefault:"0"`
+	HashWait        time.Duration `env:"PASSWORD_HASH_WAIT" envDefault:"2s"`
+}
+
+// Registration задаёт режим регистрации: open — без ограничений, token — по
+// общему токену Token, invite — по одноразовым приглашениям администраторов.
+type Registration struct {
+	Mode      string        `env:"REGISTRATION_MODE" envDefault:"open"`
+	Token     string        `env:"REGISTRATION_TOKEN"`
+	InviteTTL time.Duration `env:"REGISTRATION_INVITE_TTL" envDefault:"168h"`
+}
+
+// AdminConfig перечисляет пользователей, которые получают роль admin при
+// запуске. Удаление из списка роль не отнимает.
+type AdminConfig struct {
+	UserIDs []string `env:"ADMIN_USER_IDS"`
+}
+
+// Cache выбирает хранилище кэша: redis, memory или none. Нулевой TTL
+// отключает кэширование соответствующих данных.
+type Cache struct {
+	Driver           string        `env:"CACHE_DRIVER" envDefault:"memory"`
+	RedisAddr        string        `env:"CACHE_REDIS_ADDR" envDefault:"localhost:6379"`
+	RedisPassword    string        `env:"CACHE_REDIS_PASSWORD"`
+	RedisDB          int           `env:"CACHE_REDIS_DB" envDefault:"0"`
+	MemoryMaxEntries int           `env:"CACHE_MEMORY_MAX_ENTRIES" envDefault:"10000"`
+	DocumentTTL      time.Duration `env:"CACHE_DOCUMENT_TTL" envDefault:"1m"`
+	AccessTTL        time.Duration `env:"CACHE_ACCESS_TTL" envDefault:"1m"`
+	TokenTTL         time.Duration `env:"CACHE_TOKEN_TTL" envDefault:"5m"`
+	RoleTTL          time.Duration `env:"CACHE_ROLE_TTL" envDefault:"1m"`
+
+	// Локальный LRU перед проверкой токенов; нулевой размер отключает его.
+	TokenLRUSize     int           `env:"CACHE_TOKEN_LRU_SIZE" envDefault:"10000"`
+	TokenLRUTTL      time.Duration `env:"CACHE_TOKEN_LRU_TTL" envDefault:"30s"`
+	TokenNegativeTTL time.Duration `env:"CACHE_TOKEN_NEGATIVE_TTL" envDefault:"5s"`
+}
 
 func LoadConfig(log *slog.Logger, path string) *Config {
 	const op = "config.LoadConfig"
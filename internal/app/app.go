@@ -2,23 +2,35 @@ package app
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
 	"time"
 
 	"github.com/DENFNC/web-test/config"
+	"github.com/DENFNC/web-test/internal/domain"
+	"github.com/DENFNC/web-test/internal/infra/cache"
+	"github.com/DENFNC/web-test/internal/infra/notifier"
 	"github.com/DENFNC/web-test/internal/infra/psql"
 	"github.com/DENFNC/web-test/internal/infra/psql/repository"
+	"github.com/DENFNC/web-test/internal/infra/scanner"
+	"github.com/DENFNC/web-test/internal/infra/signer"
+	"github.com/DENFNC/web-test/internal/infra/storage"
 	"github.com/DENFNC/web-test/internal/service"
 	handler "github.com/DENFNC/web-test/internal/transport/http"
+	"github.com/DENFNC/web-test/internal/worker"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type App struct {
 	*slog.Logger
 	*http.ServeMux
-	Addr string
+	Handler http.Handler
+	Addr    string
+	workers []worker.Worker
+	stop    context.CancelFunc
 }
 
 func NewApp(
@@ -39,19 +51,174 @@ func NewApp(
 		os.Exit(1)
 	}
 
-	authRepo := repository.NewAuthRepository(log, db)
-	authService := service.NewAuthService(log, authRepo)
+	appCache, err := initCache(ctx, cfg)
+	if err != nil {
+		log.Error(
+			"Failed to initialize cache",
+			slog.String("err", err.Error()),
+		)
+		os.Exit(1)
+	}
+
+	var tokenLRU *cache.LRUCache
+	if cfg.Cache.TokenLRUSize > 0 {
+		tokenLRU = cache.NewLRUCache(cfg.Cache.TokenLRUSize)
+	}
+
+	tokenSigner, err := initSigner(cfg)
+	if err != nil {
+		log.Error(
+			"Failed to load token signing keys",
+			slog.String("err", err.Error()),
+		)
+		os.Exit(1)
+	}
+
+	var authRepoOptions []repository.AuthRepositoryOption
+	if tokenSigner != nil {
+		authRepoOptions = append(authRepoOptions, repository.WithAccessTokenSigner(tokenSigner))
+	}
+
+	authRepo := repository.NewAuthRepository(log, db, authRepoOptions...)
+	userNotifier, err := initNotifier(log, cfg)
+	if err != nil {
+		log.Error(
+			"Failed to initialize notifier",
+			slog.String("err", err.Error()),
+		)
+		os.Exit(1)
+	}
+
+	loginAttempts, err := initLoginAttemptStore(cfg, authRepo, appCache)
+	if err != nil {
+		log.Error(
+			"Failed to initialize login throttle",
+			slog.String("err", err.Error()),
+		)
+		os.Exit(1)
+	}
+
+	registration, err := initRegistration(cfg)
+	if err != nil {
+		log.Error(
+			"Invalid registration settings",
+			slog.String("err", err.Error()),
+		)
+		os.Exit(1)
+	}
+
+	auditRepo := repository.NewAuditRepository(log, db)
+	auditService := service.NewAuditService(log, auditRepo)
+
+	authService := service.NewAuthService(log, authRepo, domain.TokenTTL{
+		Access:  cfg.AuthConfig.AccessTokenTTL,
+		Refresh: cfg.AuthConfig.RefreshTokenTTL,
+	}, append(
+		authOptions(cfg, appCache, tokenLRU, loginAttempts),
+		service.WithPasswordReset(userNotifier, service.PasswordResetConfig{
+			CodeTTL:     cfg.ResetConfig.CodeTTL,
+			MaxAttempts: cfg.ResetConfig.MaxAttempts,
+		}),
+		service.WithTOTPIssuer(cfg.AuthConfig.TOTPIssuer),
+		service.WithHashConcurrency(cfg.LoginThrottle.HashConcurrency, cfg.LoginThrottle.HashWait),
+		service.WithAuthAuditLog(auditService),
+		service.WithRegistration(registration),
+	)...)
+	authService.BootstrapAdmins(ctx, cfg.AdminConfig.UserIDs)
+
+	store, err := initStorage(cfg)
+	if err != nil {
+		log.Error(
+			"Failed to initialize storage",
+			slog.String("err", err.Error()),
+		)
+		os.Exit(1)
+	}
+
+	webhookRepo := repository.NewWebhookRepository(log, db)
+	webhookService := service.NewWebhookService(log, webhookRepo, service.WebhookConfig{
+		MaxAttempts: cfg.WebhookConfig.MaxAttempts,
+		BaseBackoff: cfg.WebhookConfig.BaseBackoff,
+		MaxBackoff:  cfg.WebhookConfig.MaxBackoff,
+		Timeout:     cfg.WebhookConfig.Timeout,
+
+		AllowPrivateNetworks: cfg.WebhookConfig.AllowPrivateNetworks,
+	})
+
+	eventRepo := repository.NewEventRepository(log, db)
+	notificationService := service.NewNotificationService(log, eventRepo, cfg.EventsConfig.Retention)
+
+	eventListener := psql.NewListener(log, db, "user_events", notificationService.Dispatch)
+	eventListener.OnReset(notificationService.Reset)
 
 	docRepo := repository.NewDocumentRepository(log, db)
-	docService := service.NewDocumentService(docRepo, authRepo)
+	docService := service.NewDocumentService(log, docRepo, authRepo, store, domain.Quota{
+		Bytes:     cfg.QuotaConfig.DefaultBytes,
+		Documents: cfg.QuotaConfig.DefaultDocuments,
+	}, service.LockConfig{
+		DefaultTTL: cfg.LockConfig.DefaultTTL,
+		MaxTTL:     cfg.LockConfig.MaxTTL,
+	}, append(
+		documentOptions(cfg, appCache, tokenLRU),
+		service.WithAuditLog(auditService),
+		service.WithEventPublisher(webhookService),
+		service.WithEventPublisher(notificationService),
+	)...)
+
+	tokenListener := psql.NewListener(log, db, "auth_tokens_revoked", docService.EvictToken)
+	tokenListener.OnReset(docService.ResetTokens)
 
 	handler.NewAuthHandler(log, mux, authService)
-	handler.NewDocumentHandler(log, mux, docService)
+	handler.NewInviteHandler(log, mux, authService)
+	handler.NewUserHandler(log, mux, authService, auditService)
+	handler.NewAPIKeyHandler(log, mux, authService, auditService)
+	if tokenSigner != nil {
+		handler.NewJWKSHandler(log, mux, tokenSigner, authService)
+	}
+	handler.NewDocumentHandler(log, mux, docService, auditService, cfg.StorageConfig.MaxUploadBytes)
+	handler.NewAuditHandler(log, mux, auditService, docService)
+	handler.NewWebhookHandler(log, mux, webhookService)
+	handler.NewEventHandler(log, mux, notificationService, cfg.EventsConfig.KeepAlive)
+
+	workers := []worker.Worker{
+		worker.NewBatchWorker(
+			log,
+			"expired-documents",
+			docService.DeleteExpiredDocuments,
+			cfg.ExpiryConfig.SweepInterval,
+			cfg.ExpiryConfig.SweepBatchSize,
+		),
+		worker.NewBatchWorker(
+			log,
+			"webhook-deliveries",
+			webhookService.ProcessDeliveries,
+			cfg.WebhookConfig.PollInterval,
+			cfg.WebhookConfig.BatchSize,
+		),
+		worker.NewBatchWorker(
+			log,
+			"user-events-retention",
+			notificationService.DeleteOldEvents,
+			cfg.EventsConfig.PruneInterval,
+			cfg.EventsConfig.PruneBatchSize,
+		),
+		worker.NewBatchWorker(
+			log,
+			"expired-tokens",
+			authService.DeleteExpiredTokens,
+			cfg.AuthConfig.TokenSweepInterval,
+			cfg.AuthConfig.TokenSweepBatchSize,
+		),
+		eventListener,
+		tokenListener,
+	}
 
 	return &App{
 		Logger:   log,
 		ServeMux: mux,
+		Handler:  handler.Authenticate(docService, authService, authService, cfg.AuthConfig.LegacyTokenSunset, cfg.StorageConfig.MaxUploadBytes)(mux),
 		Addr:     cfg.AppConfig.URL,
+		workers:  workers,
 	}
 }
 
@@ -60,12 +227,18 @@ func (app *App) MustStart() {
 
 	log := app.Logger.With("op", op)
 
+	ctx, cancel := context.WithCancel(context.Background())
+	app.stop = cancel
+	for _, w := range app.workers {
+		go w.Run(ctx)
+	}
+
 	log.Info(
 		"Starting the server",
 		slog.String("addr", app.Addr),
 	)
 
-	if err := http.ListenAndServe(app.Addr, app.ServeMux); err != nil {
+	if err := http.ListenAndServe(app.Addr, app.Handler); err != nil {
 		log.Error(
 			"Failed to start tcp server",
 			slog.String("err", err.Error()),
@@ -74,6 +247,12 @@ func (app *App) MustStart() {
 	}
 }
 
+func (app *App) Stop() {
+	if app.stop != nil {
+		app.stop()
+	}
+}
+
 func initDatabase(ctx context.Context, cfg *config.Config) (*pgxpool.Pool, error) {
 	db, err := psql.NewDatabase(ctx, cfg.DBConfig.URL,
 		psql.WithMaxConns(cfg.DBConfig.MaxConns),
@@ -88,3 +267,168 @@ func initDatabase(ctx context.Context, cfg *config.Config) (*pgxpool.Pool, error
 
 	return db.Pool, nil
 }
+
+func initStorage(cfg *config.Config) (service.Storage, error) {
+	local, err := storage.NewLocalStorage(cfg.StorageConfig.UploadDir)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cfg.StorageConfig.EncryptionKeys) == 0 {
+		return local, nil
+	}
+
+	keys, err := storage.NewKeyring(
+		cfg.StorageConfig.EncryptionKeys,
+		cfg.StorageConfig.EncryptionActiveKey,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return storage.NewEncryptedStorage(local, keys), nil
+}
+
+// initSigner возвращает nil, если ключи подписи не заданы и access-токены
+// выдаются случайными строками.
+func initSigner(cfg *config.Config) (*signer.Signer, error) {
+	if len(cfg.AuthConfig.JWTKeys) == 0 {
+		return nil, nil
+	}
+
+	return signer.NewSigner(
+		cfg.AuthConfig.JWTKeys,
+		cfg.AuthConfig.JWTActiveKey,
+		cfg.AuthConfig.JWTIssuer,
+		cfg.AuthConfig.JWTAudience,
+	)
+}
+
+func initNotifier(log *slog.Logger, cfg *config.Config) (service.Notifier, error) {
+	switch cfg.Notifier.Driver {
+	case "smtp":
+		smtpNotifier, err := notifier.NewSMTPNotifier(
+			cfg.Notifier.SMTPAddr,
+			cfg.Notifier.SMTPFrom,
+			cfg.Notifier.SMTPUsername,
+			cfg.Notifier.SMTPPassword,
+			cfg.Notifier.SMTPTimeout,
+		)
+		if err != nil {
+			return nil, err
+		}
+		return smtpNotifier, nil
+	case "log", "":
+		return notifier.NewLogNotifier(log), nil
+	default:
+		return nil, fmt.Errorf("unknown notifier driver %q", cfg.Notifier.Driver)
+	}
+}
+
+// initCache возвращает nil, если кэширование отключено.
+func initCache(ctx context.Context, cfg *config.Config) (service.Cache, error) {
+	switch cfg.Cache.Driver {
+	case "redis":
+		redisCache, err := cache.NewRedisCache(ctx, cfg.Cache.RedisAddr, cfg.Cache.RedisPassword, cfg.Cache.RedisDB)
+		if err != nil {
+			return nil, err
+		}
+		return redisCache, nil
+	case "memory":
+		return cache.NewMemoryCache(cfg.Cache.MemoryMaxEntries), nil
+	case "none", "":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown cache driver %q", cfg.Cache.Driver)
+	}
+}
+
+func initRegistration(cfg *config.Config) (service.RegistrationConfig, error) {
+	registration := service.RegistrationConfig{
+		Mode:      cfg.Registration.Mode,
+		Token:     cfg.Registration.Token,
+		InviteTTL: cfg.Registration.InviteTTL,
+	}
+
+	switch registration.Mode {
+	case domain.RegistrationOpen, domain.RegistrationInvite:
+	case domain.RegistrationToken:
+		if registration.Token == "" {
+			return registration, errors.New("REGISTRATION_TOKEN is required in token registration mode")
+		}
+	default:
+		return registration, fmt.Errorf("unknown registration mode %q", registration.Mode)
+	}
+	return registration, nil
+}
+
+// initLoginAttemptStore возвращает nil, если ограничение входа отключено.
+func initLoginAttemptStore(cfg *config.Config, authRepo *repository.AuthRepository, appCache service.Cache) (service.LoginAttemptStore, error) {
+	switch cfg.LoginThrottle.Store {
+	case "postgres", "":
+		return authRepo, nil
+	case "cache":
+		if appCache == nil {
+			return nil, errors.New("login throttle store cache requires CACHE_DRIVER other than none")
+		}
+		return service.NewCacheLoginAttemptStore(appCache), nil
+	case "none":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown login throttle store %q", cfg.LoginThrottle.Store)
+	}
+}
+
+func authOptions(cfg *config.Config, appCache service.Cache, tokenLRU *cache.LRUCache, loginAttempts service.LoginAttemptStore) []service.AuthOption {
+	var options []service.AuthOption
+
+	if loginAttempts != nil {
+		options = append(options, service.WithLoginThrottle(loginAttempts, service.LoginThrottleConfig{
+			MaxLoginFailures: cfg.LoginThrottle.MaxLoginFailures,
+			MaxIPFailures:    cfg.LoginThrottle.MaxIPFailures,
+			FailureWindow:    cfg.LoginThrottle.FailureWindow,
+			LockoutDuration:  cfg.LoginThrottle.LockoutDuration,
+			BaseDelay:        cfg.LoginThrottle.BaseDelay,
+			MaxDelay:         cfg.LoginThrottle.MaxDelay,
+		}))
+	}
+
+	if appCache != nil {
+		options = append(options, service.WithTokenCache(appCache))
+		options = append(options, service.WithRoleCache(appCache, cfg.Cache.RoleTTL))
+	}
+	if tokenLRU != nil {
+		options = append(options, service.WithTokenCache(tokenLRU))
+	}
+
+	return options
+}
+
+func documentOptions(cfg *config.Config, appCache service.Cache, tokenLRU *cache.LRUCache) []service.DocumentOption {
+	var options []service.DocumentOption
+
+	if tokenLRU != nil {
+		options = append(options, service.WithTokenLRU(
+			tokenLRU,
+			cfg.Cache.TokenLRUTTL,
+			cfg.Cache.TokenNegativeTTL,
+		))
+	}
+
+	if appCache != nil {
+		options = append(options, service.WithCache(appCache, service.CacheTTL{
+			Document: cfg.Cache.DocumentTTL,
+			Access:   cfg.Cache.AccessTTL,
+			Token:    cfg.Cache.TokenTTL,
+		}))
+	}
+
+	if cfg.ScanConfig.ClamdAddr != "" {
+		options = append(options, service.WithScanner(
+			scanner.NewClamdScanner(cfg.ScanConfig.ClamdAddr, cfg.ScanConfig.Timeout),
+			cfg.ScanConfig.FailClosed,
+		))
+	}
+
+	return options
+}
@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"net/netip"
+	"time"
+)
+
+// APIKeyPrefix отличает API-ключи от токенов доступа в заголовке
+// Authorization.
+const APIKeyPrefix = "wsk_"
+
+// Области действия API-ключей.
+const (
+	ScopeDocsRead  = "docs:read"
+	ScopeDocsWrite = "docs:write"
+	ScopeDocsShare = "docs:share"
+	// ScopeTokensRevoked — для сервисов, проверяющих JWT по подписи.
+	ScopeTokensRevoked = "tokens:revoked"
+)
+
+var scopePermissions = map[string]Permission{
+	ScopeDocsRead:      PermDocumentRead,
+	ScopeDocsWrite:     PermDocumentWrite,
+	ScopeDocsShare:     PermDocumentShare,
+	ScopeTokensRevoked: PermTokenRevokedRead,
+}
+
+func ValidScope(scope string) bool {
+	_, ok := scopePermissions[scope]
+	return ok
+}
+
+// ScopePermissions возвращает разрешения, которые дают области scopes.
+func ScopePermissions(scopes []string) []Permission {
+	perms := make([]Permission, 0, len(scopes))
+	for _, scope := range scopes {
+		if perm, ok := scopePermissions[scope]; ok {
+			perms = append(perms, perm)
+		}
+	}
+	return perms
+}
+
+// APIKey — именованный ключ пользователя или сервисной учётной записи. Сам
+// ключ Key показывается только при создании, хранится его SHA-256; Prefix
+// позволяет узнать ключ в списке. Пустой AllowedNetworks разрешает любые
+// адреса.
+type APIKey struct {
+	ID              string
+	UserID          string
+	Name            string
+	Key             string
+	Prefix          string
+	Scopes          []string
+	AllowedNetworks []string
+	ExpiresAt       time.Time
+	LastUsedAt      time.Time
+	LastUsedIP      string
+	CreatedAt       time.Time
+}
+
+// AllowsIP проверяет адрес клиента по списку разрешённых сетей.
+func (k *APIKey) AllowsIP(ip string) bool {
+	if len(k.AllowedNetworks) == 0 {
+		return true
+	}
+
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, network := range k.AllowedNetworks {
+		if prefix, err := netip.ParsePrefix(network); err == nil && prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseNetwork приводит адрес или сеть в нотации CIDR к виду сети: одиночный
+// адрес становится /32 или /128.
+func ParseNetwork(s string) (string, error) {
+	if prefix, err := netip.ParsePrefix(s); err == nil {
+		return prefix.Masked().String(), nil
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return "", ErrInvalidNetwork
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()).String(), nil
+}
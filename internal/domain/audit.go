@@ -0,0 +1,45 @@
+package domain
+
+import "time"
+
+const (
+	AuditDocumentUpload   = "document.upload"
+	AuditDocumentDownload = "document.download"
+	AuditDocumentUpdate   = "document.update"
+	AuditDocumentDelete   = "document.delete"
+	AuditDocumentExpire   = "document.expire"
+	AuditAccessGrant      = "access.grant"
+	AuditAccessRevoke     = "access.revoke"
+	AuditDocumentLock     = "document.lock"
+	AuditDocumentUnlock   = "document.unlock"
+	AuditDocumentCopy     = "document.copy"
+	AuditDocumentTransfer = "document.transfer"
+	AuditTransferOffer    = "transfer.offer"
+	AuditTransferCancel   = "transfer.cancel"
+	AuditLoginLockout     = "auth.lockout"
+	AuditAdminOverride    = "admin.override"
+	AuditRoleChange       = "user.role"
+	AuditAPIKeyCreate     = "apikey.create"
+	AuditAPIKeyRevoke     = "apikey.revoke"
+)
+
+type AuditEvent struct {
+	ID         int64
+	ActorID    string
+	DocumentID string
+	TargetID   string
+	Action     string
+	IP         string
+	UserAgent  string
+	CreatedAt  time.Time
+}
+
+type AuditFilter struct {
+	ActorID    string
+	DocumentID string
+	Action     string
+	From       time.Time
+	To         time.Time
+	Limit      uint
+	Offset     uint
+}
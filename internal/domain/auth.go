@@ -1,10 +1,113 @@
 package domain
 
-import "time"
+import (
+	"crypto"
+	"time"
+)
 
 type AuthToken struct {
+	UserID     string
+	Token      string
+	IsRevoked  bool
+	FamilyID   string
+	ExpiresAt  time.Time
+	LastUsedAt time.Time
+	IP         string
+	UserAgent  string
+	CreatedAt  time.Time
+}
+
+// Session — один вход пользователя; идентификатор совпадает с семейством
+// токенов.
+type Session struct {
+	ID         string
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+	IP         string
+	UserAgent  string
+	Current    bool
+}
+
+// SessionInfo описывает клиента, открывшего или продлившего сессию.
+type SessionInfo struct {
+	IP        string
+	UserAgent string
+}
+
+// TokenPair — короткоживущий access-токен и refresh-токен, которым его можно
+// обновить.
+type TokenPair struct {
+	AccessToken      string
+	AccessExpiresAt  time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+}
+
+type TokenTTL struct {
+	Access  time.Duration
+	Refresh time.Duration
+}
+
+type RefreshToken struct {
+	TokenHash string
 	UserID    string
-	Token     string
+	FamilyID  string
+	ExpiresAt time.Time
+	UsedAt    time.Time
 	IsRevoked bool
+}
+
+// AccessClaims — содержимое access-токена в формате JWT.
+type AccessClaims struct {
+	ID        string
+	Subject   string
+	SessionID string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+// RevokedToken — отозванный до истечения access-токен в формате JWT.
+type RevokedToken struct {
+	ID        string
+	SessionID string
+	ExpiresAt time.Time
+}
+
+// PublicKey — открытый ключ проверки подписи access-токенов.
+type PublicKey struct {
+	ID        string
+	Algorithm string
+	Key       crypto.PublicKey
+}
+
+// Notification — сообщение пользователю, например код восстановления пароля.
+type Notification struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// TOTP — секрет второго фактора. Нулевой EnabledAt означает, что
+// подключение ещё не подтверждено кодом.
+type TOTP struct {
+	UserID    string
+	Secret    string
+	LastStep  int64
+	EnabledAt time.Time
 	CreatedAt time.Time
 }
+
+// TOTPEnrollment — данные для добавления аккаунта в приложение-аутентификатор.
+type TOTPEnrollment struct {
+	Secret string
+	URI    string
+}
+
+// LoginAttempts — неудачные попытки входа по одному логину или адресу.
+// Запись перестаёт учитываться после ExpiresAt.
+type LoginAttempts struct {
+	Failures      int32
+	LastFailureAt time.Time
+	LockedUntil   time.Time
+	ExpiresAt     time.Time
+}
@@ -3,11 +3,29 @@ package domain
 import "time"
 
 type Document struct {
-	ID        string
-	FileName  string
-	MimeType  string
-	HasFile   bool
-	IsPublic  bool
-	OwnerID   string
-	CreatedAt time.Time
+	ID            string
+	FileName      string
+	MimeType      string
+	HasFile       bool
+	IsPublic      bool
+	OwnerID       string
+	Size          int64
+	ScanStatus    string
+	ScanSignature string
+	Quarantined   bool
+	ExpiresAt     time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	Checksum      string
+	Version       int64
+	SharedWith    []string
+	// SharedBlob означает, что файл используется другими документами и его
+	// нельзя удалять вместе с этим документом.
+	SharedBlob bool
+}
+
+type DocumentFilter struct {
+	UserID string
+	Limit  uint
+	Offset uint
 }
@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrQuotaExceeded   = errors.New("storage quota exceeded")
+	ErrNotFound        = errors.New("not found")
+	ErrLocked          = errors.New("document is locked by another user")
+	ErrVersionMismatch = errors.New("document version mismatch")
+	ErrInvalidToken    = errors.New("invalid token")
+	ErrTokenReused     = errors.New("refresh token reuse detected")
+
+	ErrInvalidCredentials = errors.New("invalid login or password")
+	ErrInvalidResetCode   = errors.New("invalid or expired reset code")
+	ErrOTPRequired        = errors.New("two-factor code required")
+	ErrInvalidOTP         = errors.New("invalid two-factor code")
+	ErrTOTPEnabled        = errors.New("two-factor authentication is already enabled")
+	ErrServerBusy         = errors.New("server is busy")
+
+	ErrRegistrationTokenRequired = errors.New("registration token required")
+	ErrInvalidRegistrationToken  = errors.New("invalid registration token")
+	ErrInviteRequired            = errors.New("invite code required")
+	ErrInvalidInvite             = errors.New("invalid, used or expired invite code")
+
+	ErrInvalidRole = errors.New("invalid role")
+
+	ErrInvalidScope       = errors.New("invalid api key scope")
+	ErrInvalidNetwork     = errors.New("invalid IP address or network")
+	ErrAPIKeyIPNotAllowed = errors.New("api key is not allowed from this address")
+)
+
+// ThrottleError означает, что вход временно запрещён из-за неудачных
+// попыток; повторить можно через RetryAfter.
+type ThrottleError struct {
+	RetryAfter time.Duration
+	Locked     bool
+}
+
+func (e *ThrottleError) Error() string {
+	if e.Locked {
+		return "too many failed login attempts, temporarily locked"
+	}
+	return "too many failed login attempts, retry later"
+}
@@ -0,0 +1,48 @@
+package domain
+
+import "time"
+
+const (
+	EventDocumentCreated     = "document.created"
+	EventDocumentUpdated     = "document.updated"
+	EventDocumentDeleted     = "document.deleted"
+	EventDocumentTransferred = "document.transferred"
+	EventAccessGranted       = "access.granted"
+)
+
+var EventTypes = []string{
+	EventDocumentCreated,
+	EventDocumentUpdated,
+	EventDocumentDeleted,
+	EventDocumentTransferred,
+	EventAccessGranted,
+}
+
+// DocumentEvent описывает изменение, о котором нужно сообщить внешним
+// подписчикам. UserID заполняется для событий доступа, а при передаче
+// документа содержит прежнего владельца. Audience содержит пользователей
+// с доступом к документу на момент события.
+type DocumentEvent struct {
+	Type       string    `json:"type"`
+	DocumentID string    `json:"document_id"`
+	OwnerID    string    `json:"owner_id"`
+	UserID     string    `json:"user_id,omitempty"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Audience   []string  `json:"-"`
+}
+
+// UserEvent — событие, адресованное конкретному получателю. ID растёт
+// монотонно и служит идентификатором для возобновления потока.
+type UserEvent struct {
+	ID          int64  `json:"id"`
+	RecipientID string `json:"recipient_id"`
+	DocumentEvent
+}
+
+// Recipients возвращает владельца и адресата события доступа.
+func (e *DocumentEvent) Recipients() []string {
+	if e.UserID != "" && e.UserID != e.OwnerID {
+		return []string{e.OwnerID, e.UserID}
+	}
+	return []string{e.OwnerID}
+}
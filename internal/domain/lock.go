@@ -0,0 +1,18 @@
+package domain
+
+import "time"
+
+type DocumentLock struct {
+	DocumentID string
+	UserID     string
+	AcquiredAt time.Time
+	ExpiresAt  time.Time
+}
+
+// DocumentPatch содержит изменяемые поля метаданных; nil означает, что поле
+// не меняется.
+type DocumentPatch struct {
+	MimeType  *string
+	IsPublic  *bool
+	ExpiresAt *time.Time
+}
@@ -0,0 +1,21 @@
+package domain
+
+import "slices"
+
+// Principal — аутентифицированный вызывающий, его роль и токен, которым он
+// представился. Для API-ключа заполнены APIKeyID и Scopes: ключ получает
+// только разрешения роли, входящие в его области действия.
+type Principal struct {
+	UserID   string
+	Role     string
+	Token    string
+	APIKeyID string
+	Scopes   []Permission
+}
+
+func (p *Principal) Can(perm Permission) bool {
+	if !HasPermission(p.Role, perm) {
+		return false
+	}
+	return p.APIKeyID == "" || slices.Contains(p.Scopes, perm)
+}
@@ -0,0 +1,69 @@
+package domain
+
+import "slices"
+
+// Роли пользователей.
+const (
+	RoleAdmin    = "admin"
+	RoleUser     = "user"
+	RoleReadOnly = "read-only"
+	// RoleService — для сервисных учётных записей других систем.
+	RoleService = "service"
+)
+
+type Permission string
+
+const (
+	PermDocumentRead  Permission = "document:read"
+	PermDocumentWrite Permission = "document:write"
+	// PermDocumentShare разрешает выдавать доступ к своим документам.
+	PermDocumentShare Permission = "document:share"
+	// PermDocumentOverride даёт доступ к любому документу, как у владельца.
+	// Каждое такое обращение записывается в журнал аудита.
+	PermDocumentOverride Permission = "document:override"
+	PermWebhookManage    Permission = "webhook:manage"
+	PermAuditRead        Permission = "audit:read"
+	PermInviteManage     Permission = "invite:manage"
+	PermRoleManage       Permission = "role:manage"
+	// PermAPIKeyManage разрешает управлять API-ключами любых пользователей,
+	// например сервисных учётных записей.
+	PermAPIKeyManage Permission = "apikey:manage"
+	// PermTokenRevokedRead разрешает читать список отозванных JWT.
+	PermTokenRevokedRead Permission = "token:revoked:read"
+)
+
+var rolePermissions = map[string][]Permission{
+	RoleAdmin: {
+		PermDocumentRead,
+		PermDocumentWrite,
+		PermDocumentShare,
+		PermDocumentOverride,
+		PermWebhookManage,
+		PermAuditRead,
+		PermInviteManage,
+		PermRoleManage,
+		PermAPIKeyManage,
+		PermTokenRevokedRead,
+	},
+	RoleUser: {
+		PermDocumentRead,
+		PermDocumentWrite,
+		PermDocumentShare,
+		PermWebhookManage,
+	},
+	RoleReadOnly: {
+		PermDocumentRead,
+	},
+	RoleService: {
+		PermTokenRevokedRead,
+	},
+}
+
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+func HasPermission(role string, perm Permission) bool {
+	return slices.Contains(rolePermissions[role], perm)
+}
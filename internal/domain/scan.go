@@ -0,0 +1,13 @@
+package domain
+
+const (
+	ScanStatusUnscanned = "unscanned"
+	ScanStatusClean     = "clean"
+	ScanStatusInfected  = "infected"
+	ScanStatusFailed    = "failed"
+)
+
+type ScanResult struct {
+	Infected  bool
+	Signature string
+}
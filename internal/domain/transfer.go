@@ -0,0 +1,13 @@
+package domain
+
+import "time"
+
+// DocumentTransfer — предложение передать документ другому пользователю.
+// KeepAccess оставляет прежнему владельцу доступ к документу.
+type DocumentTransfer struct {
+	DocumentID string
+	FromUserID string
+	ToUserID   string
+	KeepAccess bool
+	CreatedAt  time.Time
+}
@@ -0,0 +1,28 @@
+package domain
+
+type Quota struct {
+	Bytes     int64
+	Documents int64
+}
+
+// Usage содержит текущее потребление пользователя и действующие для него
+// лимиты. Нулевой лимит означает отсутствие ограничения.
+type Usage struct {
+	UserID         string
+	BytesUsed      int64
+	DocumentsCount int64
+	QuotaBytes     int64
+	QuotaDocuments int64
+}
+
+// Allows сообщает, укладывается ли изменение потребления в квоту.
+// Уменьшение потребления разрешено всегда.
+func (u *Usage) Allows(bytes, documents int64) bool {
+	if u.QuotaBytes > 0 && bytes > 0 && u.BytesUsed+bytes > u.QuotaBytes {
+		return false
+	}
+	if u.QuotaDocuments > 0 && documents > 0 && u.DocumentsCount+documents > u.QuotaDocuments {
+		return false
+	}
+	return true
+}
@@ -6,10 +6,31 @@ type User struct {
 	ID        string
 	Login     string
 	Password  string
+	Email     string
+	Role      string
 	CreatedAt time.Time
 }
 
 type UserCredentials struct {
+	UserID   string
 	Password string
-	Token    string
+}
+
+// Режимы регистрации.
+const (
+	RegistrationOpen   = "open"
+	RegistrationToken  = "token"
+	RegistrationInvite = "invite"
+)
+
+// Invite — одноразовое приглашение на регистрацию. Код показывается только
+// при создании.
+type Invite struct {
+	ID        string
+	Code      string
+	CreatedBy string
+	UsedBy    string
+	UsedAt    time.Time
+	ExpiresAt time.Time
+	CreatedAt time.Time
 }
@@ -0,0 +1,41 @@
+package domain
+
+import "time"
+
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+type Webhook struct {
+	ID        string
+	OwnerID   string
+	URL       string
+	Secret    string
+	Events    []string
+	IsActive  bool
+	CreatedAt time.Time
+}
+
+type WebhookDelivery struct {
+	ID             int64
+	WebhookID      string
+	EventType      string
+	Payload        []byte
+	Status         string
+	Attempts       int32
+	NextAttemptAt  time.Time
+	LastStatusCode int32
+	LastError      string
+	CreatedAt      time.Time
+	DeliveredAt    time.Time
+}
+
+// DeliveryTask — доставка, захваченная обработчиком, вместе с данными
+// вебхука, нужными для отправки.
+type DeliveryTask struct {
+	WebhookDelivery
+	URL    string
+	Secret string
+}
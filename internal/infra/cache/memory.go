@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type entry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+// MemoryCache хранит значения в памяти процесса. Подходит для одного
+// экземпляра приложения: другие экземпляры не видят инвалидацию.
+type MemoryCache struct {
+	mu         sync.Mutex
+	entries    map[string]entry
+	maxEntries int
+}
+
+func NewMemoryCache(maxEntries int) *MemoryCache {
+	return &MemoryCache{
+		entries:    make(map[string]entry),
+		maxEntries: maxEntries,
+	}
+}
+
+func (c *MemoryCache) Get(_ context.Context, key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	if time.Now().After(e.expiresAt) {
+		delete(c.entries, key)
+		return nil, false, nil
+	}
+	return e.value, true, nil
+}
+
+func (c *MemoryCache) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.entries[key]; !ok && c.maxEntries > 0 && len(c.entries) >= c.maxEntries {
+		c.evict()
+	}
+	c.entries[key] = entry{
+		value:     value,
+		expiresAt: time.Now().Add(ttl),
+	}
+	return nil
+}
+
+func (c *MemoryCache) Delete(_ context.Context, keys ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		delete(c.entries, key)
+	}
+	return nil
+}
+
+// evict освобождает место: сначала удаляет просроченные записи, а если их
+// нет — произвольную.
+func (c *MemoryCache) evict() {
+	now := time.Now()
+	for key, e := range c.entries {
+		if now.After(e.expiresAt) {
+			delete(c.entries, key)
+		}
+	}
+	if len(c.entries) < c.maxEntries {
+		return
+	}
+	for key := range c.entries {
+		delete(c.entries, key)
+		return
+	}
+}
@@ -0,0 +1,28 @@
+package notifier
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/DENFNC/web-test/internal/domain"
+)
+
+// LogNotifier пишет сообщения в журнал вместо отправки. Подходит только для
+// разработки: в журнал попадают коды восстановления.
+type LogNotifier struct {
+	*slog.Logger
+}
+
+func NewLogNotifier(log *slog.Logger) *LogNotifier {
+	return &LogNotifier{Logger: log}
+}
+
+func (n *LogNotifier) Notify(_ context.Context, msg domain.Notification) error {
+	n.Logger.Info(
+		"Notification",
+		slog.String("to", msg.To),
+		slog.String("subject", msg.Subject),
+		slog.String("body", msg.Body),
+	)
+	return nil
+}
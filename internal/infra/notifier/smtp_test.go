@@ -0,0 +1,156 @@
+package notifier
+
+import (
+	"context"
+	"io"
+	"mime"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DENFNC/web-test/internal/domain"
+)
+
+// smtpMessage — то, что получил тестовый SMTP-сервер.
+type smtpMessage struct {
+	from string
+	to   []string
+	data string
+}
+
+// fakeSMTP принимает одно соединение и ведёт минимальный SMTP-диалог без
+// STARTTLS и AUTH. Получатель rejectRcpt отклоняется с кодом 550.
+func fakeSMTP(t *testing.T, rejectRcpt string) (string, <-chan smtpMessage) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	received := make(chan smtpMessage, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		tp := textproto.NewConn(conn)
+		var msg smtpMessage
+		tp.PrintfLine("220 localhost ESMTP")
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+			verb, arg, _ := strings.Cut(line, " ")
+
+			switch strings.ToUpper(verb) {
+			case "EHLO", "HELO":
+				tp.PrintfLine("250 localhost")
+			case "MAIL":
+				msg.from = strings.Trim(strings.TrimPrefix(arg, "FROM:"), "<>")
+				tp.PrintfLine("250 OK")
+			case "RCPT":
+				rcpt := strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>")
+				if rcpt == rejectRcpt {
+					tp.PrintfLine("550 No such user")
+					continue
+				}
+				msg.to = append(msg.to, rcpt)
+				tp.PrintfLine("250 OK")
+			case "DATA":
+				tp.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+				data, err := io.ReadAll(tp.DotReader())
+				if err != nil {
+					return
+				}
+				msg.data = string(data)
+				tp.PrintfLine("250 OK")
+			case "QUIT":
+				tp.PrintfLine("221 Bye")
+				received <- msg
+				return
+			default:
+				tp.PrintfLine("502 Command not implemented")
+			}
+		}
+	}()
+
+	return ln.Addr().String(), received
+}
+
+func TestSMTPNotifier_Notify(t *testing.T) {
+	addr, received := fakeSMTP(t, "")
+
+	n, err := NewSMTPNotifier(addr, "noreply@example.com", "", "", 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = n.Notify(context.Background(), domain.Notification{
+		To:      "alice@example.com",
+		Subject: "Сброс пароля",
+		Body:    "Your password reset code is 428913.\nIt expires in 15m0s.\n",
+	})
+	if err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+
+	var msg smtpMessage
+	select {
+	case msg = <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not receive the message")
+	}
+
+	if msg.from != "noreply@example.com" {
+		t.Errorf("MAIL FROM = %q, want noreply@example.com", msg.from)
+	}
+	if len(msg.to) != 1 || msg.to[0] != "alice@example.com" {
+		t.Errorf("RCPT TO = %v, want [alice@example.com]", msg.to)
+	}
+
+	parsed, err := mail.ReadMessage(strings.NewReader(msg.data))
+	if err != nil {
+		t.Fatalf("cannot parse message: %v", err)
+	}
+	if to := parsed.Header.Get("To"); to != "alice@example.com" {
+		t.Errorf("To = %q, want alice@example.com", to)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil || subject != "Сброс пароля" {
+		t.Errorf("Subject = %q (%v), want %q", subject, err, "Сброс пароля")
+	}
+
+	body, err := io.ReadAll(parsed.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(body), "428913") {
+		t.Errorf("body %q does not contain the code", body)
+	}
+}
+
+func TestSMTPNotifier_RecipientRejected(t *testing.T) {
+	addr, _ := fakeSMTP(t, "nobody@example.com")
+
+	n, err := NewSMTPNotifier(addr, "noreply@example.com", "", "", 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = n.Notify(context.Background(), domain.Notification{
+		To:      "nobody@example.com",
+		Subject: "Password reset code",
+		Body:    "Your password reset code is 428913.\n",
+	})
+	if err == nil {
+		t.Fatal("Notify() error = nil, want rejected recipient")
+	}
+}
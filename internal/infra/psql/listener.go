@@ -0,0 +1,88 @@
+package psql
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const listenRetryDelay = time.Second
+
+// Listener держит отдельное соединение с LISTEN на канал и передаёт
+// полезную нагрузку уведомлений в handle. При обрыве соединение
+// переустанавливается.
+type Listener struct {
+	*slog.Logger
+	pool    *pgxpool.Pool
+	channel string
+	handle  func(payload string)
+	onReset func()
+}
+
+func NewListener(log *slog.Logger, pool *pgxpool.Pool, channel string, handle func(payload string)) *Listener {
+	return &Listener{
+		Logger:  log,
+		pool:    pool,
+		channel: channel,
+		handle:  handle,
+	}
+}
+
+// OnReset задаёт функцию, вызываемую после переподключения: уведомления,
+// пришедшие во время обрыва, потеряны, и подписчикам может понадобиться
+// перечитать состояние.
+func (l *Listener) OnReset(fn func()) {
+	l.onReset = fn
+}
+
+func (l *Listener) Run(ctx context.Context) {
+	const op = "psql.Listener.Run"
+
+	log := l.Logger.With("op", op, "channel", l.channel)
+
+	for first := true; ; first = false {
+		if !first && l.onReset != nil {
+			l.onReset()
+		}
+
+		err := l.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Error(
+			"Listener connection lost",
+			slog.String("err", err.Error()),
+		)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(listenRetryDelay):
+		}
+	}
+}
+
+func (l *Listener) listen(ctx context.Context) error {
+	pooled, err := l.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	// Соединение с активным LISTEN нельзя возвращать в пул.
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{l.channel}.Sanitize()); err != nil {
+		return err
+	}
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		l.handle(notification.Payload)
+	}
+}
@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/DENFNC/web-test/internal/domain"
+	"github.com/DENFNC/web-test/internal/models"
+	"github.com/DENFNC/web-test/internal/utils/dbutils"
+	"github.com/DENFNC/web-test/internal/utils/mapping"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/jackc/pgx/v5"
+)
+
+// apiKeyTouchInterval — не чаще какого интервала обновляется время
+// последнего использования ключа, чтобы каждый запрос не писал в базу.
+const apiKeyTouchInterval = "1 minute"
+
+func (repo *AuthRepository) SaveAPIKey(ctx context.Context, key *domain.APIKey, keyHash string) error {
+	var mdlKey models.APIKey
+	if err := mapping.MapStructModel(key, &mdlKey); err != nil {
+		return err
+	}
+	mdlKey.KeyHash.String, mdlKey.KeyHash.Valid = keyHash, true
+
+	return dbutils.WithTransaction(ctx, repo.Pool, func(tx pgx.Tx) error {
+		stmt, args, err := repo.DialectWrapper.
+			Insert("api_keys").
+			Rows(mdlKey).
+			Prepared(true).
+			ToSQL()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, stmt, args...); err != nil {
+			return err
+		}
+
+		scopes := make([]any, 0, len(key.Scopes))
+		for _, scope := range key.Scopes {
+			scopes = append(scopes, goqu.Record{
+				"api_key_id": key.ID,
+				"scope":      scope,
+			})
+		}
+		if err := repo.insertIgnore(ctx, tx, "api_key_scopes", scopes); err != nil {
+			return err
+		}
+
+		networks := make([]any, 0, len(key.AllowedNetworks))
+		for _, network := range key.AllowedNetworks {
+			networks = append(networks, goqu.Record{
+				"api_key_id": key.ID,
+				"network":    network,
+			})
+		}
+		return repo.insertIgnore(ctx, tx, "api_key_networks", networks)
+	})
+}
+
+func (repo *AuthRepository) insertIgnore(ctx context.Context, tx pgx.Tx, table string, records []any) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	stmt, args, err := repo.DialectWrapper.
+		Insert(table).
+		Rows(records...).
+		OnConflict(goqu.DoNothing()).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, stmt, args...)
+	return err
+}
+
+func (repo *AuthRepository) ListAPIKeys(ctx context.Context, userID string) ([]domain.APIKey, error) {
+	return repo.listAPIKeys(ctx, goqu.Ex{"k.user_id": userID})
+}
+
+// GetAPIKeyByHash возвращает pgx.ErrNoRows, если ключа нет. Срок действия и
+// адрес клиента проверяет вызывающий.
+func (repo *AuthRepository) GetAPIKeyByHash(ctx context.Context, keyHash string) (*domain.APIKey, error) {
+	keys, err := repo.listAPIKeys(ctx, goqu.Ex{"k.key_hash": keyHash})
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+	return &keys[0], nil
+}
+
+func (repo *AuthRepository) listAPIKeys(ctx context.Context, where goqu.Ex) ([]domain.APIKey, error) {
+	stmt, args, err := repo.DialectWrapper.
+		Select(
+			goqu.I("k.id"),
+			goqu.I("k.user_id"),
+			goqu.I("k.name"),
+			goqu.I("k.key_hash"),
+			goqu.I("k.prefix"),
+			goqu.I("k.expires_at"),
+			goqu.I("k.last_used_at"),
+			goqu.I("k.last_used_ip"),
+			goqu.I("k.created_at"),
+			goqu.L("ARRAY(SELECT s.scope FROM api_key_scopes s WHERE s.api_key_id = k.id ORDER BY s.scope)").As("scopes"),
+			goqu.L("ARRAY(SELECT n.network FROM api_key_networks n WHERE n.api_key_id = k.id ORDER BY n.network)").As("allowed_networks"),
+		).
+		From(goqu.T("api_keys").As("k")).
+		Where(where).
+		Order(goqu.I("k.created_at").Asc()).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.Pool.Query(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	mdlKeys, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.APIKeyWithScopes])
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]domain.APIKey, 0, len(mdlKeys))
+	for i := range mdlKeys {
+		var key domain.APIKey
+		if err := mapping.MapStructModelToDomain(&mdlKeys[i].APIKey, &key); err != nil {
+			return nil, err
+		}
+		key.Scopes = mdlKeys[i].Scopes
+		key.AllowedNetworks = mdlKeys[i].AllowedNetworks
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// DeleteAPIKey возвращает ErrNotFound, если у пользователя нет такого ключа.
+func (repo *AuthRepository) DeleteAPIKey(ctx context.Context, id, userID string) error {
+	stmt, args, err := repo.DialectWrapper.
+		Delete("api_keys").
+		Where(goqu.Ex{"id": id, "user_id": userID}).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return err
+	}
+
+	tag, err := repo.Pool.Exec(ctx, stmt, args...)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// TouchAPIKey отмечает использование ключа. Время обновляется не чаще
+// apiKeyTouchInterval, если адрес клиента не сменился.
+func (repo *AuthRepository) TouchAPIKey(ctx context.Context, id, ip string) error {
+	stmt, args, err := repo.DialectWrapper.
+		Update("api_keys").
+		Set(goqu.Record{
+			"last_used_at": goqu.L("NOW()"),
+			"last_used_ip": ip,
+		}).
+		Where(
+			goqu.Ex{"id": id},
+			goqu.Or(
+				goqu.C("last_used_at").IsNull(),
+				goqu.C("last_used_at").Lt(goqu.L("NOW() - ?::interval", apiKeyTouchInterval)),
+				goqu.L("last_used_ip IS DISTINCT FROM ?", ip),
+			),
+		).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return err
+	}
+
+	_, err = repo.Pool.Exec(ctx, stmt, args...)
+	return err
+}
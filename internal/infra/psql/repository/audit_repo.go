@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/DENFNC/web-test/internal/domain"
+	"github.com/DENFNC/web-test/internal/models"
+	"github.com/DENFNC/web-test/internal/utils/mapping"
+	"github.com/doug-martin/goqu/v9"
+	_ "github.com/doug-martin/goqu/v9/dialect/postgres"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type AuditRepository struct {
+	*slog.Logger
+	*goqu.DialectWrapper
+	*pgxpool.Pool
+}
+
+func NewAuditRepository(log *slog.Logger, pool *pgxpool.Pool) *AuditRepository {
+	dialect := goqu.Dialect("postgres")
+	return &AuditRepository{
+		Logger:         log,
+		DialectWrapper: &dialect,
+		Pool:           pool,
+	}
+}
+
+func (repo *AuditRepository) SaveEvent(ctx context.Context, event *domain.AuditEvent) error {
+	var mdlEvent models.AuditEvent
+	if err := mapping.MapStructModel(event, &mdlEvent); err != nil {
+		return err
+	}
+
+	stmt, args, err := repo.DialectWrapper.
+		Insert("audit_events").
+		Rows(mdlEvent).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return err
+	}
+	_, err = repo.Pool.Exec(ctx, stmt, args...)
+	return err
+}
+
+func (repo *AuditRepository) ListEvents(ctx context.Context, filter domain.AuditFilter) ([]*domain.AuditEvent, error) {
+	query := repo.DialectWrapper.
+		Select("id", "actor_id", "document_id", "target_id", "action", "ip", "user_agent", "created_at").
+		From("audit_events").
+		Order(goqu.C("created_at").Desc(), goqu.C("id").Desc()).
+		Limit(filter.Limit).
+		Offset(filter.Offset)
+
+	if filter.ActorID != "" {
+		query = query.Where(goqu.Ex{"actor_id": filter.ActorID})
+	}
+	if filter.DocumentID != "" {
+		query = query.Where(goqu.Ex{"document_id": filter.DocumentID})
+	}
+	if filter.Action != "" {
+		query = query.Where(goqu.Ex{"action": filter.Action})
+	}
+	if !filter.From.IsZero() {
+		query = query.Where(goqu.C("created_at").Gte(filter.From))
+	}
+	if !filter.To.IsZero() {
+		query = query.Where(goqu.C("created_at").Lt(filter.To))
+	}
+
+	stmt, args, err := query.Prepared(true).ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.Pool.Query(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	mdlEvents, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.AuditEvent])
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]*domain.AuditEvent, 0, len(mdlEvents))
+	for i := range mdlEvents {
+		var event domain.AuditEvent
+		if err := mapping.MapStructModelToDomain(&mdlEvents[i], &event); err != nil {
+			return nil, err
+		}
+		events = append(events, &event)
+	}
+	return events, nil
+}
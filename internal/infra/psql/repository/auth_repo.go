@@ -3,9 +3,12 @@ package repository
 import (
 	"context"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
-	"fmt"
+	"encoding/hex"
+	"errors"
 	"log/slog"
+	"time"
 
 	"github.com/DENFNC/web-test/internal/domain"
 	"github.com/DENFNC/web-test/internal/models"
@@ -13,43 +16,178 @@ import (
 	"github.com/DENFNC/web-test/internal/utils/mapping"
 	"github.com/doug-martin/goqu/v9"
 	_ "github.com/doug-martin/goqu/v9/dialect/postgres"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+var authTokenColumns = []any{
+	"user_id", "token", "is_revoked", "family_id", "expires_at",
+	"last_used_at", "ip", "user_agent", "created_at",
+}
+
+// AccessTokenSigner выпускает access-токены в виде подписанных JWT.
+type AccessTokenSigner interface {
+	Sign(claims domain.AccessClaims) (string, error)
+	Claims(token string) (domain.AccessClaims, error)
+}
+
 type AuthRepository struct {
 	*slog.Logger
 	*goqu.DialectWrapper
 	*pgxpool.Pool
+	signer AccessTokenSigner
+}
+
+type AuthRepositoryOption func(repo *AuthRepository)
+
+// WithAccessTokenSigner заменяет случайные access-токены на JWT. Токен
+// целиком хранится в auth_tokens, поэтому отзыв и сессии работают так же,
+// как для случайных токенов.
+func WithAccessTokenSigner(signer AccessTokenSigner) AuthRepositoryOption {
+	return func(repo *AuthRepository) {
+		repo.signer = signer
+	}
 }
 
 func NewAuthRepository(
 	log *slog.Logger,
 	pool *pgxpool.Pool,
+	options ...AuthRepositoryOption,
 ) *AuthRepository {
 	dialect := goqu.Dialect("postgres")
 
-	return &AuthRepository{
+	repo := &AuthRepository{
 		Logger:         log,
 		DialectWrapper: &dialect,
 		Pool:           pool,
 	}
+	for _, option := range options {
+		option(repo)
+	}
+
+	return repo
 }
 
-func (repo *AuthRepository) GetByID(ctx context.Context, id string) (*domain.UserCredentials, error) {
+// GetCredentialsByLogin возвращает идентификатор и хэш пароля пользователя.
+func (repo *AuthRepository) GetCredentialsByLogin(ctx context.Context, login string) (*domain.UserCredentials, error) {
+	return repo.getCredentials(ctx, goqu.Ex{"login": login})
+}
+
+func (repo *AuthRepository) GetCredentialsByUserID(ctx context.Context, userID string) (*domain.UserCredentials, error) {
+	return repo.getCredentials(ctx, goqu.Ex{"id": userID})
+}
+
+func (repo *AuthRepository) GetUserByLogin(ctx context.Context, login string) (*domain.User, error) {
+	return repo.getUser(ctx, goqu.Ex{"login": login})
+}
+
+func (repo *AuthRepository) GetUserByID(ctx context.Context, userID string) (*domain.User, error) {
+	return repo.getUser(ctx, goqu.Ex{"id": userID})
+}
+
+func (repo *AuthRepository) getUser(ctx context.Context, where goqu.Ex) (*domain.User, error) {
+	stmt, args, err := repo.DialectWrapper.
+		Select("id", "login", "password_hash", "email", "role", "created_at").
+		From("users").
+		Where(where).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.Pool.Query(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	mdlUser, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.User])
+	if err != nil {
+		return nil, err
+	}
+
+	var user domain.User
+	if err := mapping.MapStructModelToDomain(&mdlUser, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// ChangePassword сохраняет новый хэш пароля и в той же транзакции отзывает
+// все токены пользователя, кроме сессии currentToken.
+func (repo *AuthRepository) ChangePassword(ctx context.Context, userID, passwordHash, currentToken string) ([]string, error) {
+	var revoked []string
+	err := dbutils.WithTransaction(ctx, repo.Pool, func(tx pgx.Tx) error {
+		if err := repo.updatePassword(ctx, tx, userID, passwordHash); err != nil {
+			return err
+		}
+
+		stmt, args, err := repo.DialectWrapper.
+			Select("family_id").
+			From("auth_tokens").
+			Where(goqu.Ex{"token": currentToken, "user_id": userID}).
+			Prepared(true).
+			ToSQL()
+		if err != nil {
+			return err
+		}
+
+		var familyID pgtype.UUID
+		if err := tx.QueryRow(ctx, stmt, args...).Scan(&familyID); err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+
+		refreshWhere := []exp.Expression{goqu.Ex{"user_id": userID, "is_revoked": false}}
+		authWhere := []exp.Expression{
+			goqu.Ex{"user_id": userID, "is_revoked": false},
+			goqu.C("token").Neq(currentToken),
+		}
+		if familyID.Valid {
+			current := uuid.UUID(familyID.Bytes).String()
+			refreshWhere = append(refreshWhere, goqu.C("family_id").Neq(current))
+			authWhere = append(authWhere, goqu.L("family_id IS DISTINCT FROM ?", current))
+		}
+
+		revoked, err = repo.revokeWhere(ctx, tx, refreshWhere, authWhere)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return revoked, nil
+}
+
+func (repo *AuthRepository) updatePassword(ctx context.Context, tx pgx.Tx, userID, passwordHash string) error {
+	stmt, args, err := repo.DialectWrapper.
+		Update("users").
+		Set(goqu.Record{"password_hash": passwordHash}).
+		Where(goqu.Ex{"id": userID}).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return err
+	}
+
+	tag, err := tx.Exec(ctx, stmt, args...)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (repo *AuthRepository) getCredentials(ctx context.Context, where goqu.Ex) (*domain.UserCredentials, error) {
 	stmt, args, err := repo.DialectWrapper.
 		Select(
-			goqu.I("users.password_hash"),
-			goqu.I("auth_tokens.token"),
+			goqu.C("id").As("user_id"),
+			goqu.C("password_hash"),
 		).
 		From("users").
-		LeftJoin(
-			goqu.T("auth_tokens"),
-			goqu.On(goqu.Ex{
-				"users.id": goqu.I("auth_tokens.user_id"),
-			}),
-		).
+		Where(where).
 		Prepared(true).
 		ToSQL()
 	if err != nil {
@@ -60,7 +198,6 @@ func (repo *AuthRepository) GetByID(ctx context.Context, id string) (*domain.Use
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
 	creds, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.UserCredentials])
 	if err != nil {
@@ -68,7 +205,6 @@ func (repo *AuthRepository) GetByID(ctx context.Context, id string) (*domain.Use
 	}
 
 	var userCreds domain.UserCredentials
-	fmt.Println(userCreds)
 	if err := mapping.MapStructModelToDomain(creds, &userCreds); err != nil {
 		return nil, err
 	}
@@ -82,57 +218,490 @@ func (repo *AuthRepository) SaveUser(ctx context.Context, user *domain.User) (st
 		return "", err
 	}
 
-	var login string
+	return repo.insertUser(ctx, repo.Pool, &mdlUser)
+}
+
+// GetActiveToken возвращает неотозванный и непросроченный access-токен и
+// отмечает время его использования.
+func (repo *AuthRepository) GetActiveToken(ctx context.Context, token string) (*domain.AuthToken, error) {
+	stmt, args, err := repo.DialectWrapper.
+		Update("auth_tokens").
+		Set(goqu.Record{"last_used_at": goqu.L("NOW()")}).
+		Where(
+			goqu.Ex{"token": token, "is_revoked": false},
+			goqu.C("expires_at").Gt(goqu.L("NOW()")),
+		).
+		Returning(authTokenColumns...).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.Pool.Query(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	mdlToken, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.AuthToken])
+	if err != nil {
+		return nil, err
+	}
+
+	var authToken domain.AuthToken
+	if err := mapping.MapStructModelToDomain(&mdlToken, &authToken); err != nil {
+		return nil, err
+	}
+	return &authToken, nil
+}
+
+// IssueTokens выдаёт пару токенов, начинающую новое семейство.
+func (repo *AuthRepository) IssueTokens(ctx context.Context, userID string, ttl domain.TokenTTL, info domain.SessionInfo) (*domain.TokenPair, error) {
+	var pair *domain.TokenPair
 	err := dbutils.WithTransaction(ctx, repo.Pool, func(tx pgx.Tx) error {
 		var err error
+		pair, err = repo.issueTokens(ctx, tx, userID, uuid.New().String(), ttl, info)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pair, nil
+}
 
-		login, err = repo.insertUser(ctx, tx, &mdlUser)
+// RotateRefreshToken обменивает refresh-токен на новую пару того же
+// семейства. Повторное предъявление уже обменянного токена означает, что
+// он утёк: семейство отзывается целиком. В обоих случаях вызывающему
+// возвращаются ставшие недействительными access-токены, чтобы убрать их из
+// кэшей.
+func (repo *AuthRepository) RotateRefreshToken(ctx context.Context, refreshToken string, ttl domain.TokenTTL, info domain.SessionInfo) (*domain.TokenPair, []string, error) {
+	var (
+		pair  *domain.TokenPair
+		stale []string
+	)
+	err := dbutils.WithTransaction(ctx, repo.Pool, func(tx pgx.Tx) error {
+		stmt, args, err := repo.DialectWrapper.
+			Select("token_hash", "user_id", "family_id", "expires_at", "used_at", "is_revoked").
+			From("refresh_tokens").
+			Where(goqu.Ex{"token_hash": hashToken(refreshToken)}).
+			ForUpdate(exp.Wait).
+			Prepared(true).
+			ToSQL()
 		if err != nil {
 			return err
 		}
 
-		token, err := generateToken(128)
+		rows, err := tx.Query(ctx, stmt, args...)
 		if err != nil {
 			return err
 		}
+		mdlToken, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.RefreshToken])
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.ErrInvalidToken
+		}
+		if err != nil {
+			return err
+		}
+
+		var token domain.RefreshToken
+		if err := mapping.MapStructModelToDomain(&mdlToken, &token); err != nil {
+			return err
+		}
 
-		authToken := models.AuthToken{
-			UserID: mdlUser.ID,
-			Token: pgtype.Text{
-				String: token,
-				Valid:  true,
-			},
+		switch {
+		case token.IsRevoked || !token.ExpiresAt.After(time.Now()):
+			return domain.ErrInvalidToken
+		case !token.UsedAt.IsZero():
+			stale, err = repo.revokeTokens(ctx, tx, token.UserID, token.FamilyID)
+			return err
 		}
 
-		if err := repo.insertAuthToken(ctx, tx, &authToken); err != nil {
+		stmt, args, err = repo.DialectWrapper.
+			Update("refresh_tokens").
+			Set(goqu.Record{"used_at": goqu.L("NOW()")}).
+			Where(goqu.Ex{"token_hash": token.TokenHash}).
+			Prepared(true).
+			ToSQL()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, stmt, args...); err != nil {
 			return err
 		}
 
-		return nil
+		stmt, args, err = repo.DialectWrapper.
+			Select("token").
+			From("auth_tokens").
+			Where(goqu.Ex{"family_id": token.FamilyID}).
+			Prepared(true).
+			ToSQL()
+		if err != nil {
+			return err
+		}
+		rows, err = tx.Query(ctx, stmt, args...)
+		if err != nil {
+			return err
+		}
+		if stale, err = pgx.CollectRows(rows, pgx.RowTo[string]); err != nil {
+			return err
+		}
+		if err := repo.denyAccessTokens(ctx, tx, stale); err != nil {
+			return err
+		}
+
+		pair, err = repo.issueTokens(ctx, tx, token.UserID, token.FamilyID, ttl, info)
+		return err
 	})
 	if err != nil {
-		return "", err
+		return nil, nil, err
 	}
-
-	return login, nil
+	if pair == nil {
+		return nil, stale, domain.ErrTokenReused
+	}
+	return pair, stale, nil
 }
 
-func (repo *AuthRepository) GetUserIDByToken(ctx context.Context, token string) (string, error) {
+// ListSessions возвращает действующие сессии пользователя; сессия с
+// токеном currentToken помечается как текущая.
+func (repo *AuthRepository) ListSessions(ctx context.Context, userID, currentToken string) ([]domain.Session, error) {
 	stmt, args, err := repo.DialectWrapper.
-		Select("user_id").
+		Select(
+			goqu.C("family_id").As("id"),
+			goqu.C("created_at"),
+			goqu.C("last_used_at"),
+			goqu.C("ip"),
+			goqu.C("user_agent"),
+			goqu.L("token = ?", currentToken).As("current"),
+		).
 		From("auth_tokens").
-		Where(goqu.Ex{"token": token, "is_revoked": false}).
+		Where(
+			goqu.Ex{"user_id": userID, "is_revoked": false},
+			goqu.C("family_id").IsNotNull(),
+			goqu.Or(
+				goqu.C("expires_at").Gt(goqu.L("NOW()")),
+				goqu.L("EXISTS ?", repo.activeRefreshTokens()),
+			),
+		).
+		Order(goqu.C("last_used_at").Desc().NullsLast(), goqu.C("created_at").Desc()).
 		Prepared(true).
 		ToSQL()
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	row := repo.Pool.QueryRow(ctx, stmt, args...)
-	var userID string
-	if err := row.Scan(&userID); err != nil {
-		return "", err
+
+	rows, err := repo.Pool.Query(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
 	}
-	return userID, nil
+	mdlSessions, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.Session])
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]domain.Session, 0, len(mdlSessions))
+	for _, mdlSession := range mdlSessions {
+		var session domain.Session
+		if err := mapping.MapStructModelToDomain(&mdlSession, &session); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, nil
+}
+
+// RevokeSession завершает сессию пользователя и возвращает отозванные
+// access-токены.
+func (repo *AuthRepository) RevokeSession(ctx context.Context, userID, sessionID string) ([]string, error) {
+	var revoked []string
+	err := dbutils.WithTransaction(ctx, repo.Pool, func(tx pgx.Tx) error {
+		var err error
+		revoked, err = repo.revokeTokens(ctx, tx, userID, sessionID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(revoked) == 0 {
+		return nil, domain.ErrNotFound
+	}
+	return revoked, nil
+}
+
+// RevokeAllSessions завершает все сессии пользователя.
+func (repo *AuthRepository) RevokeAllSessions(ctx context.Context, userID string) ([]string, error) {
+	var revoked []string
+	err := dbutils.WithTransaction(ctx, repo.Pool, func(tx pgx.Tx) error {
+		var err error
+		revoked, err = repo.revokeTokens(ctx, tx, userID, "")
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return revoked, nil
+}
+
+// DeleteExpiredTokens удаляет пачку просроченных access- и refresh-токенов,
+// кодов восстановления пароля, счётчиков неудачных входов и записей об
+// отозванных JWT.
+// Строка сессии остаётся, пока у неё есть действующий refresh-токен.
+func (repo *AuthRepository) DeleteExpiredTokens(ctx context.Context, limit uint) (int, error) {
+	expiredAccess := repo.DialectWrapper.
+		Select("token").
+		From("auth_tokens").
+		Where(
+			goqu.C("expires_at").Lte(goqu.L("NOW()")),
+			goqu.L("NOT EXISTS ?", repo.activeRefreshTokens()),
+		).
+		Limit(limit)
+
+	expiredRefresh := repo.DialectWrapper.
+		Select("token_hash").
+		From("refresh_tokens").
+		Where(goqu.C("expires_at").Lte(goqu.L("NOW()"))).
+		Limit(limit)
+
+	expiredResets := repo.DialectWrapper.
+		Select("user_id").
+		From("password_resets").
+		Where(goqu.C("expires_at").Lte(goqu.L("NOW()"))).
+		Limit(limit)
+
+	expiredAttempts := repo.DialectWrapper.
+		Select("key").
+		From("login_attempts").
+		Where(goqu.C("expires_at").Lte(goqu.L("NOW()"))).
+		Limit(limit)
+
+	expiredDenied := repo.DialectWrapper.
+		Select("jti").
+		From("revoked_tokens").
+		Where(goqu.C("expires_at").Lte(goqu.L("NOW()"))).
+		Limit(limit)
+
+	var deleted int
+	for _, batch := range []struct {
+		table, key string
+		expired    *goqu.SelectDataset
+	}{
+		{"auth_tokens", "token", expiredAccess},
+		{"refresh_tokens", "token_hash", expiredRefresh},
+		{"password_resets", "user_id", expiredResets},
+		{"login_attempts", "key", expiredAttempts},
+		{"revoked_tokens", "jti", expiredDenied},
+	} {
+		stmt, args, err := repo.DialectWrapper.
+			Delete(batch.table).
+			Where(goqu.L("? IN ?", goqu.C(batch.key), batch.expired)).
+			Prepared(true).
+			ToSQL()
+		if err != nil {
+			return deleted, err
+		}
+
+		tag, err := repo.Pool.Exec(ctx, stmt, args...)
+		if err != nil {
+			return deleted, err
+		}
+		deleted += int(tag.RowsAffected())
+	}
+	return deleted, nil
+}
+
+// activeRefreshTokens выбирает действующие refresh-токены семейства строки
+// auth_tokens из внешнего запроса.
+func (repo *AuthRepository) activeRefreshTokens() *goqu.SelectDataset {
+	return repo.DialectWrapper.
+		Select(goqu.L("1")).
+		From("refresh_tokens").
+		Where(
+			goqu.I("refresh_tokens.family_id").Eq(goqu.I("auth_tokens.family_id")),
+			goqu.Ex{"refresh_tokens.is_revoked": false, "refresh_tokens.used_at": nil},
+			goqu.I("refresh_tokens.expires_at").Gt(goqu.L("NOW()")),
+		)
+}
+
+// issueTokens выдаёт новую пару токенов семейства. Строка сессии в
+// auth_tokens создаётся при входе и перезаписывается при обновлении.
+func (repo *AuthRepository) issueTokens(ctx context.Context, tx pgx.Tx, userID, familyID string, ttl domain.TokenTTL, info domain.SessionInfo) (*domain.TokenPair, error) {
+	now := time.Now()
+	access, err := repo.newAccessToken(userID, familyID, now, now.Add(ttl.Access))
+	if err != nil {
+		return nil, err
+	}
+	refresh, err := generateToken(64)
+	if err != nil {
+		return nil, err
+	}
+
+	pair := &domain.TokenPair{
+		AccessToken:      access,
+		AccessExpiresAt:  now.Add(ttl.Access),
+		RefreshToken:     refresh,
+		RefreshExpiresAt: now.Add(ttl.Refresh),
+	}
+
+	var authToken models.AuthToken
+	if err := mapping.MapStructModel(&domain.AuthToken{
+		UserID:     userID,
+		Token:      access,
+		FamilyID:   familyID,
+		ExpiresAt:  pair.AccessExpiresAt,
+		LastUsedAt: now,
+		IP:         info.IP,
+		UserAgent:  info.UserAgent,
+	}, &authToken); err != nil {
+		return nil, err
+	}
+
+	stmt, args, err := repo.DialectWrapper.
+		Insert("auth_tokens").
+		Rows(authToken).
+		OnConflict(goqu.DoUpdate("family_id", goqu.Record{
+			"token":        goqu.I("excluded.token"),
+			"expires_at":   goqu.I("excluded.expires_at"),
+			"last_used_at": goqu.I("excluded.last_used_at"),
+			"ip":           goqu.I("excluded.ip"),
+			"user_agent":   goqu.I("excluded.user_agent"),
+		})).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, stmt, args...); err != nil {
+		return nil, err
+	}
+
+	stmt, args, err = repo.DialectWrapper.
+		Insert("refresh_tokens").
+		Rows(goqu.Record{
+			"token_hash": hashToken(refresh),
+			"user_id":    userID,
+			"family_id":  familyID,
+			"expires_at": pair.RefreshExpiresAt,
+		}).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, stmt, args...); err != nil {
+		return nil, err
+	}
+
+	return pair, nil
+}
+
+// revokeTokens отзывает токены пользователя — все или только одного
+// семейства — и возвращает отозванные access-токены.
+func (repo *AuthRepository) revokeTokens(ctx context.Context, tx pgx.Tx, userID, familyID string) ([]string, error) {
+	where := goqu.Ex{"user_id": userID, "is_revoked": false}
+	if familyID != "" {
+		where["family_id"] = familyID
+	}
+
+	return repo.revokeWhere(ctx, tx, []exp.Expression{where}, []exp.Expression{where})
+}
+
+// revokeWhere отзывает refresh- и access-токены по отдельным условиям и
+// возвращает отозванные access-токены.
+func (repo *AuthRepository) revokeWhere(ctx context.Context, tx pgx.Tx, refreshWhere, authWhere []exp.Expression) ([]string, error) {
+	stmt, args, err := repo.DialectWrapper.
+		Update("refresh_tokens").
+		Set(goqu.Record{"is_revoked": true}).
+		Where(refreshWhere...).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, stmt, args...); err != nil {
+		return nil, err
+	}
+
+	stmt, args, err = repo.DialectWrapper.
+		Update("auth_tokens").
+		Set(goqu.Record{"is_revoked": true}).
+		Where(authWhere...).
+		Returning("token").
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	revoked, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, err
+	}
+
+	return revoked, repo.denyAccessTokens(ctx, tx, revoked)
+}
+
+// denyAccessTokens заносит jti отозванных JWT в revoked_tokens, откуда их
+// забирают сервисы, проверяющие токены только по подписи. Случайные токены,
+// выданные без ключей подписи, пропускаются.
+func (repo *AuthRepository) denyAccessTokens(ctx context.Context, tx pgx.Tx, tokens []string) error {
+	if repo.signer == nil {
+		return nil
+	}
+
+	records := make([]any, 0, len(tokens))
+	for _, token := range tokens {
+		claims, err := repo.signer.Claims(token)
+		if err != nil || claims.ID == "" || !claims.ExpiresAt.After(time.Now()) {
+			continue
+		}
+
+		var mdlToken models.RevokedToken
+		if err := mapping.MapStructModel(&domain.RevokedToken{
+			ID:        claims.ID,
+			SessionID: claims.SessionID,
+			ExpiresAt: claims.ExpiresAt,
+		}, &mdlToken); err != nil {
+			return err
+		}
+		records = append(records, mdlToken)
+	}
+
+	return repo.insertIgnore(ctx, tx, "revoked_tokens", records)
+}
+
+// ListRevokedTokens возвращает отозванные JWT, срок которых ещё не истёк.
+func (repo *AuthRepository) ListRevokedTokens(ctx context.Context) ([]domain.RevokedToken, error) {
+	stmt, args, err := repo.DialectWrapper.
+		Select("jti", "session_id", "expires_at").
+		From("revoked_tokens").
+		Where(goqu.C("expires_at").Gt(goqu.L("NOW()"))).
+		Order(goqu.C("expires_at").Asc()).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.Pool.Query(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	mdlTokens, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.RevokedToken])
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := make([]domain.RevokedToken, 0, len(mdlTokens))
+	for i := range mdlTokens {
+		var token domain.RevokedToken
+		if err := mapping.MapStructModelToDomain(&mdlTokens[i], &token); err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, nil
 }
 
 func (repo *AuthRepository) GetUserIDByLogin(ctx context.Context, login string) (string, error) {
@@ -153,7 +722,7 @@ func (repo *AuthRepository) GetUserIDByLogin(ctx context.Context, login string)
 	return userID, nil
 }
 
-func (repo *AuthRepository) insertUser(ctx context.Context, tx pgx.Tx, user *models.User) (string, error) {
+func (repo *AuthRepository) insertUser(ctx context.Context, db dbutils.Querier, user *models.User) (string, error) {
 	stmt, args, err := repo.DialectWrapper.
 		Insert("users").
 		Returning("login").
@@ -165,25 +734,30 @@ func (repo *AuthRepository) insertUser(ctx context.Context, tx pgx.Tx, user *mod
 	}
 
 	var login string
-	if err := tx.QueryRow(ctx, stmt, args...).Scan(&login); err != nil {
+	if err := db.QueryRow(ctx, stmt, args...).Scan(&login); err != nil {
 		return "", err
 	}
 
 	return login, nil
 }
 
-func (repo *AuthRepository) insertAuthToken(ctx context.Context, tx pgx.Tx, token *models.AuthToken) error {
-	stmt, args, err := repo.DialectWrapper.
-		Insert("auth_tokens").
-		Rows(token).
-		Prepared(true).
-		ToSQL()
-	if err != nil {
-		return err
+func (repo *AuthRepository) newAccessToken(userID, sessionID string, issuedAt, expiresAt time.Time) (string, error) {
+	if repo.signer == nil {
+		return generateToken(128)
 	}
 
-	_, err = tx.Exec(ctx, stmt, args...)
-	return err
+	return repo.signer.Sign(domain.AccessClaims{
+		ID:        uuid.New().String(),
+		Subject:   userID,
+		SessionID: sessionID,
+		IssuedAt:  issuedAt,
+		ExpiresAt: expiresAt,
+	})
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
 
 func generateToken(n int) (string, error) {
@@ -194,16 +768,51 @@ func generateToken(n int) (string, error) {
 	return base64.URLEncoding.EncodeToString(b), nil
 }
 
-func (repo *AuthRepository) RevokeToken(ctx context.Context, token string) error {
-	stmt, args, err := repo.DialectWrapper.
-		Update("auth_tokens").
-		Set(goqu.Record{"is_revoked": true}).
-		Where(goqu.Ex{"token": token, "is_revoked": false}).
-		Prepared(true).
-		ToSQL()
+// RevokeToken завершает сессию, к которой относится токен пользователя.
+// Токены, выданные до появления сессий, отзываются по одному.
+func (repo *AuthRepository) RevokeToken(ctx context.Context, userID, token string) ([]string, error) {
+	var revoked []string
+	err := dbutils.WithTransaction(ctx, repo.Pool, func(tx pgx.Tx) error {
+		stmt, args, err := repo.DialectWrapper.
+			Select("family_id").
+			From("auth_tokens").
+			Where(goqu.Ex{"token": token, "user_id": userID, "is_revoked": false}).
+			Prepared(true).
+			ToSQL()
+		if err != nil {
+			return err
+		}
+
+		var familyID pgtype.UUID
+		if err := tx.QueryRow(ctx, stmt, args...).Scan(&familyID); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return domain.ErrNotFound
+			}
+			return err
+		}
+
+		if familyID.Valid {
+			revoked, err = repo.revokeTokens(ctx, tx, userID, uuid.UUID(familyID.Bytes).String())
+			return err
+		}
+
+		stmt, args, err = repo.DialectWrapper.
+			Update("auth_tokens").
+			Set(goqu.Record{"is_revoked": true}).
+			Where(goqu.Ex{"token": token}).
+			Prepared(true).
+			ToSQL()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, stmt, args...); err != nil {
+			return err
+		}
+		revoked = []string{token}
+		return repo.denyAccessTokens(ctx, tx, revoked)
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
-	_, err = repo.Pool.Exec(ctx, stmt, args...)
-	return err
+	return revoked, nil
 }
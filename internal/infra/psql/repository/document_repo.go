@@ -2,7 +2,7 @@ package repository
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"log/slog"
 
 	"github.com/DENFNC/web-test/internal/domain"
@@ -11,10 +11,29 @@ import (
 	"github.com/DENFNC/web-test/internal/utils/mapping"
 	"github.com/doug-martin/goqu/v9"
 	_ "github.com/doug-martin/goqu/v9/dialect/postgres"
+	"github.com/doug-martin/goqu/v9/exp"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+var documentColumns = []any{
+	"id",
+	"file_name",
+	"mime_type",
+	"has_file",
+	"is_public",
+	"owner_id",
+	"size_bytes",
+	"scan_status",
+	"scan_signature",
+	"quarantined",
+	"expires_at",
+	"created_at",
+	"updated_at",
+	"checksum_sha256",
+	"version",
+}
+
 type DocumentRepository struct {
 	*slog.Logger
 	*goqu.DialectWrapper
@@ -30,7 +49,7 @@ func NewDocumentRepository(log *slog.Logger, pool *pgxpool.Pool) *DocumentReposi
 	}
 }
 
-func (repo *DocumentRepository) SaveDocument(ctx context.Context, doc *domain.Document) (string, error) {
+func (repo *DocumentRepository) SaveDocument(ctx context.Context, doc *domain.Document, defaults domain.Quota) (string, error) {
 	var mdlDoc models.Document
 	if err := mapping.MapStructModel(doc, &mdlDoc); err != nil {
 		return "", err
@@ -38,6 +57,14 @@ func (repo *DocumentRepository) SaveDocument(ctx context.Context, doc *domain.Do
 
 	var id string
 	err := dbutils.WithTransaction(ctx, repo.Pool, func(tx pgx.Tx) error {
+		usage, err := repo.lockUsage(ctx, tx, doc.OwnerID, defaults)
+		if err != nil {
+			return err
+		}
+		if !usage.Allows(doc.Size, 1) {
+			return domain.ErrQuotaExceeded
+		}
+
 		stmt, args, err := repo.DialectWrapper.
 			Insert("documents").
 			Returning("id").
@@ -50,7 +77,8 @@ func (repo *DocumentRepository) SaveDocument(ctx context.Context, doc *domain.Do
 		if err := tx.QueryRow(ctx, stmt, args...).Scan(&id); err != nil {
 			return err
 		}
-		return nil
+
+		return repo.updateUsage(ctx, tx, doc.OwnerID, doc.Size, 1)
 	})
 	if err != nil {
 		return "", err
@@ -58,6 +86,112 @@ func (repo *DocumentRepository) SaveDocument(ctx context.Context, doc *domain.Do
 	return id, nil
 }
 
+func (repo *DocumentRepository) GetUsage(ctx context.Context, userID string, defaults domain.Quota) (*domain.Usage, error) {
+	stmt, args, err := repo.usageQuery(userID, defaults).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.Pool.Query(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	mdlUsage, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.UserUsage])
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &domain.Usage{
+			UserID:         userID,
+			QuotaBytes:     defaults.Bytes,
+			QuotaDocuments: defaults.Documents,
+		}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var usage domain.Usage
+	if err := mapping.MapStructModelToDomain(&mdlUsage, &usage); err != nil {
+		return nil, err
+	}
+	return &usage, nil
+}
+
+// lockUsage блокирует строку учёта пользователя до конца транзакции, чтобы
+// параллельные загрузки не могли вместе превысить квоту.
+func (repo *DocumentRepository) lockUsage(ctx context.Context, tx pgx.Tx, userID string, defaults domain.Quota) (*domain.Usage, error) {
+	stmt, args, err := repo.DialectWrapper.
+		Insert("user_usage").
+		Rows(goqu.Record{"user_id": userID}).
+		OnConflict(goqu.DoNothing()).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, stmt, args...); err != nil {
+		return nil, err
+	}
+
+	stmt, args, err = repo.usageQuery(userID, defaults).
+		ForUpdate(exp.Wait).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	mdlUsage, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.UserUsage])
+	if err != nil {
+		return nil, err
+	}
+
+	var usage domain.Usage
+	if err := mapping.MapStructModelToDomain(&mdlUsage, &usage); err != nil {
+		return nil, err
+	}
+	return &usage, nil
+}
+
+func (repo *DocumentRepository) updateUsage(ctx context.Context, tx pgx.Tx, userID string, bytes, documents int64) error {
+	stmt, args, err := repo.DialectWrapper.
+		Update("user_usage").
+		Set(goqu.Record{
+			"bytes_used":      goqu.L("bytes_used + ?", bytes),
+			"documents_count": goqu.L("documents_count + ?", documents),
+			"updated_at":      goqu.L("NOW()"),
+		}).
+		Where(goqu.Ex{"user_id": userID}).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, stmt, args...)
+	return err
+}
+
+func (repo *DocumentRepository) usageQuery(userID string, defaults domain.Quota) *goqu.SelectDataset {
+	return repo.DialectWrapper.
+		Select(
+			goqu.C("user_id"),
+			goqu.C("bytes_used"),
+			goqu.C("documents_count"),
+			goqu.COALESCE(goqu.C("quota_bytes"), defaults.Bytes).As("quota_bytes"),
+			goqu.COALESCE(goqu.C("quota_documents"), defaults.Documents).As("quota_documents"),
+		).
+		From("user_usage").
+		Where(goqu.Ex{"user_id": userID})
+}
+
 func (repo *DocumentRepository) AddDocumentAccess(ctx context.Context, documentID, userID string) error {
 	stmt, args, err := repo.DialectWrapper.
 		Insert("document_access").
@@ -73,34 +207,60 @@ func (repo *DocumentRepository) AddDocumentAccess(ctx context.Context, documentI
 
 func (repo *DocumentRepository) GetDocumentByID(ctx context.Context, id string) (*domain.Document, error) {
 	stmt, args, err := repo.DialectWrapper.
-		Select("id", "file_name", "mime_type", "has_file", "is_public", "owner_id", "created_at").
+		Select(documentColumns...).
 		From("documents").
-		Where(goqu.Ex{"id": id}).
+		Where(goqu.Ex{"id": id}, notExpired()).
 		Prepared(true).
 		ToSQL()
 	if err != nil {
 		return nil, err
 	}
-	row := repo.Pool.QueryRow(ctx, stmt, args...)
-	var mdlDoc models.Document
-	if err := row.Scan(
-		&mdlDoc.ID,
-		&mdlDoc.FileName,
-		&mdlDoc.MimeType,
-		&mdlDoc.HasFile,
-		&mdlDoc.IsPublic,
-		&mdlDoc.OwnerID,
-		&mdlDoc.CreatedAt,
-	); err != nil {
+
+	return scanDocument(repo.Pool.QueryRow(ctx, stmt, args...))
+}
+
+// ListDocuments возвращает документы пользователя и документы, к которым
+// ему выдан доступ, от новых к старым.
+func (repo *DocumentRepository) ListDocuments(ctx context.Context, filter domain.DocumentFilter) ([]*domain.Document, error) {
+	shared := repo.DialectWrapper.
+		Select("document_id").
+		From("document_access").
+		Where(goqu.Ex{"user_id": filter.UserID})
+
+	stmt, args, err := repo.DialectWrapper.
+		Select(documentColumns...).
+		From("documents").
+		Where(
+			goqu.Or(
+				goqu.C("owner_id").Eq(filter.UserID),
+				goqu.L("? IN ?", goqu.C("id"), shared),
+			),
+			notExpired(),
+		).
+		Order(goqu.C("created_at").Desc(), goqu.C("id").Desc()).
+		Limit(filter.Limit).
+		Offset(filter.Offset).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
 		return nil, err
 	}
 
-	var doc domain.Document
-	if err := mapping.MapStructModelToDomain(&mdlDoc, &doc); err != nil {
-		fmt.Println(err)
+	rows, err := repo.Pool.Query(ctx, stmt, args...)
+	if err != nil {
 		return nil, err
 	}
-	return &doc, nil
+	defer rows.Close()
+
+	var docs []*domain.Document
+	for rows.Next() {
+		doc, err := scanDocument(rows)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+	return docs, rows.Err()
 }
 
 func (repo *DocumentRepository) HasDocumentAccess(ctx context.Context, documentID, userID string) (bool, error) {
@@ -122,15 +282,551 @@ func (repo *DocumentRepository) HasDocumentAccess(ctx context.Context, documentI
 	return true, nil
 }
 
-func (repo *DocumentRepository) DeleteDocument(ctx context.Context, id string) error {
+// DeleteExpiredDocuments удаляет пачку просроченных документов. SKIP LOCKED
+// позволяет нескольким экземплярам приложения чистить таблицу параллельно.
+func (repo *DocumentRepository) DeleteExpiredDocuments(ctx context.Context, limit uint) ([]*domain.Document, error) {
+	var docs []*domain.Document
+	err := dbutils.WithTransaction(ctx, repo.Pool, func(tx pgx.Tx) error {
+		expired := repo.DialectWrapper.
+			Select("id").
+			From("documents").
+			Where(goqu.C("expires_at").Lte(goqu.L("NOW()")))
+
+		ids, err := repo.selectIDs(ctx, tx, expired.
+			Order(goqu.C("expires_at").Asc()).
+			Limit(limit))
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+
+		// Учёт владельцев блокируется раньше документов, поэтому кандидаты
+		// выбираются без блокировки, а захватываются уже после учёта.
+		if err := repo.lockOwnerUsage(ctx, tx, ids); err != nil {
+			return err
+		}
+		ids, err = repo.selectIDs(ctx, tx, expired.
+			Where(goqu.Ex{"id": ids}).
+			Order(goqu.C("id").Asc()).
+			ForUpdate(exp.SkipLocked))
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+		if err := repo.lockFileReferences(ctx, tx, ids); err != nil {
+			return err
+		}
+
+		docs, err = repo.deleteDocuments(ctx, tx, ids)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+func (repo *DocumentRepository) selectIDs(ctx context.Context, tx pgx.Tx, query *goqu.SelectDataset) ([]string, error) {
+	stmt, args, err := query.Prepared(true).ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
+
+// DeleteDocument удаляет документ. Ненулевой ifVersion требует, чтобы
+// версия документа не изменилась, иначе возвращается domain.ErrVersionMismatch.
+func (repo *DocumentRepository) DeleteDocument(ctx context.Context, id string, ifVersion int64) (*domain.Document, error) {
+	var docs []*domain.Document
+	err := dbutils.WithTransaction(ctx, repo.Pool, func(tx pgx.Tx) error {
+		if err := repo.lockOwnerUsage(ctx, tx, []string{id}); err != nil {
+			return err
+		}
+		if err := repo.lockFileReferences(ctx, tx, []string{id}); err != nil {
+			return err
+		}
+
+		if ifVersion != 0 {
+			doc, err := repo.selectForUpdate(ctx, tx, id)
+			if err != nil {
+				return err
+			}
+			if doc.Version != ifVersion {
+				return domain.ErrVersionMismatch
+			}
+		}
+
+		var err error
+		docs, err = repo.deleteDocuments(ctx, tx, []string{id})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+	return docs[0], nil
+}
+
+func (repo *DocumentRepository) ListDocumentAccess(ctx context.Context, documentID string) ([]string, error) {
+	access, err := repo.listAccess(ctx, repo.Pool, []string{documentID})
+	if err != nil {
+		return nil, err
+	}
+	return access[documentID], nil
+}
+
+// deleteDocuments удаляет документы и возвращает их вместе со списком
+// пользователей, у которых был доступ: после удаления строки
+// document_access исчезают каскадно. Учёт владельцев и документы с общими
+// файлами вызывающий блокирует заранее: lockOwnerUsage, затем
+// lockFileReferences.
+func (repo *DocumentRepository) deleteDocuments(ctx context.Context, tx pgx.Tx, ids []string) ([]*domain.Document, error) {
+	access, err := repo.listAccess(ctx, tx, ids)
+	if err != nil {
+		return nil, err
+	}
+
 	stmt, args, err := repo.DialectWrapper.
 		Delete("documents").
-		Where(goqu.Ex{"id": id}).
+		Where(goqu.Ex{"id": ids}).
+		Returning(documentColumns...).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var docs []*domain.Document
+	for rows.Next() {
+		doc, err := scanDocument(rows)
+		if err != nil {
+			return nil, err
+		}
+		doc.SharedWith = access[doc.ID]
+		docs = append(docs, doc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	for _, doc := range docs {
+		if err := repo.updateUsage(ctx, tx, doc.OwnerID, -doc.Size, -1); err != nil {
+			return nil, err
+		}
+		if doc.SharedBlob, err = repo.fileReferenced(ctx, tx, doc.FileName); err != nil {
+			return nil, err
+		}
+	}
+	return docs, nil
+}
+
+// lockOwnerUsage блокирует в порядке user_id строки учёта владельцев
+// документов ids. Учёт блокируется раньше документов, как при загрузке,
+// замене файла и передаче, иначе встречные транзакции взаимоблокируются.
+func (repo *DocumentRepository) lockOwnerUsage(ctx context.Context, tx pgx.Tx, ids []string) error {
+	stmt, args, err := repo.DialectWrapper.
+		Select("user_id").
+		From("user_usage").
+		Where(goqu.L("? IN ?", goqu.C("user_id"), repo.DialectWrapper.
+			Select("owner_id").
+			From("documents").
+			Where(goqu.Ex{"id": ids}),
+		)).
+		Order(goqu.C("user_id").Asc()).
+		ForUpdate(exp.Wait).
 		Prepared(true).
 		ToSQL()
 	if err != nil {
 		return err
 	}
-	_, err = repo.Pool.Exec(ctx, stmt, args...)
-	return err
+
+	rows, err := tx.Query(ctx, stmt, args...)
+	if err != nil {
+		return err
+	}
+	rows.Close()
+	return rows.Err()
+}
+
+// lockFileReferences блокирует в порядке id все документы, ссылающиеся на
+// те же файлы, что и документы ids. Вызывается до удаления или замены
+// содержимого: иначе при одновременном удалении двух копий каждая
+// транзакция видела бы ещё не удалённую другую, и общий файл не удалила бы
+// ни одна.
+func (repo *DocumentRepository) lockFileReferences(ctx context.Context, tx pgx.Tx, ids []string) error {
+	stmt, args, err := repo.DialectWrapper.
+		Select("id").
+		From("documents").
+		Where(goqu.L("? IN ?", goqu.C("file_name"), repo.DialectWrapper.
+			Select("file_name").
+			From("documents").
+			Where(goqu.Ex{"id": ids}),
+		)).
+		Order(goqu.C("id").Asc()).
+		ForUpdate(exp.Wait).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return err
+	}
+
+	rows, err := tx.Query(ctx, stmt, args...)
+	if err != nil {
+		return err
+	}
+	rows.Close()
+	return rows.Err()
+}
+
+// fileReferenced сообщает, ссылается ли на файл хотя бы один документ:
+// копии документов используют общий файл.
+func (repo *DocumentRepository) fileReferenced(ctx context.Context, tx pgx.Tx, fileName string) (bool, error) {
+	stmt, args, err := repo.DialectWrapper.
+		Select(goqu.L("EXISTS ?", repo.DialectWrapper.
+			Select(goqu.L("1")).
+			From("documents").
+			Where(goqu.Ex{"file_name": fileName}),
+		)).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return false, err
+	}
+
+	var exists bool
+	err = tx.QueryRow(ctx, stmt, args...).Scan(&exists)
+	return exists, err
+}
+
+func (repo *DocumentRepository) listAccess(ctx context.Context, q dbutils.Querier, documentIDs []string) (map[string][]string, error) {
+	stmt, args, err := repo.DialectWrapper.
+		Select("document_id", "user_id").
+		From("document_access").
+		Where(goqu.Ex{"document_id": documentIDs}).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.Query(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	access := make(map[string][]string)
+	for rows.Next() {
+		var documentID, userID string
+		if err := rows.Scan(&documentID, &userID); err != nil {
+			return nil, err
+		}
+		access[documentID] = append(access[documentID], userID)
+	}
+	return access, rows.Err()
+}
+
+// UpdateDocumentContent заменяет файл документа. Возвращает документ в
+// состоянии до изменения, чтобы вызывающий мог удалить старый файл.
+func (repo *DocumentRepository) UpdateDocumentContent(ctx context.Context, doc *domain.Document, actorID string, ifVersion int64, defaults domain.Quota) (*domain.Document, error) {
+	var old *domain.Document
+	err := dbutils.WithTransaction(ctx, repo.Pool, func(tx pgx.Tx) error {
+		usage, err := repo.lockUsage(ctx, tx, doc.OwnerID, defaults)
+		if err != nil {
+			return err
+		}
+
+		if err := repo.lockFileReferences(ctx, tx, []string{doc.ID}); err != nil {
+			return err
+		}
+
+		old, err = repo.lockDocument(ctx, tx, doc.ID, actorID, ifVersion)
+		if err != nil {
+			return err
+		}
+
+		delta := doc.Size - old.Size
+		if !usage.Allows(delta, 0) {
+			return domain.ErrQuotaExceeded
+		}
+
+		stmt, args, err := repo.DialectWrapper.
+			Update("documents").
+			Set(goqu.Record{
+				"file_name":       doc.FileName,
+				"mime_type":       doc.MimeType,
+				"has_file":        true,
+				"size_bytes":      doc.Size,
+				"scan_status":     doc.ScanStatus,
+				"scan_signature":  nullableText(doc.ScanSignature),
+				"quarantined":     doc.Quarantined,
+				"checksum_sha256": nullableText(doc.Checksum),
+				"updated_at":      goqu.L("NOW()"),
+				"version":         goqu.L("version + 1"),
+			}).
+			Where(goqu.Ex{"id": doc.ID}).
+			Returning("version", "updated_at").
+			Prepared(true).
+			ToSQL()
+		if err != nil {
+			return err
+		}
+		if err := tx.QueryRow(ctx, stmt, args...).Scan(&doc.Version, &doc.UpdatedAt); err != nil {
+			return err
+		}
+		if old.SharedBlob, err = repo.fileReferenced(ctx, tx, old.FileName); err != nil {
+			return err
+		}
+
+		return repo.updateUsage(ctx, tx, doc.OwnerID, delta, 0)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return old, nil
+}
+
+func (repo *DocumentRepository) UpdateDocumentMeta(ctx context.Context, id, actorID string, ifVersion int64, patch domain.DocumentPatch) (*domain.Document, error) {
+	record := goqu.Record{}
+	if patch.MimeType != nil {
+		record["mime_type"] = *patch.MimeType
+	}
+	if patch.IsPublic != nil {
+		record["is_public"] = *patch.IsPublic
+	}
+	if patch.ExpiresAt != nil {
+		record["expires_at"] = *patch.ExpiresAt
+	}
+
+	var doc *domain.Document
+	err := dbutils.WithTransaction(ctx, repo.Pool, func(tx pgx.Tx) error {
+		var err error
+		doc, err = repo.lockDocument(ctx, tx, id, actorID, ifVersion)
+		if err != nil || len(record) == 0 {
+			return err
+		}
+		record["version"] = goqu.L("version + 1")
+		record["updated_at"] = goqu.L("NOW()")
+
+		stmt, args, err := repo.DialectWrapper.
+			Update("documents").
+			Set(record).
+			Where(goqu.Ex{"id": id}).
+			Returning(documentColumns...).
+			Prepared(true).
+			ToSQL()
+		if err != nil {
+			return err
+		}
+
+		doc, err = scanDocument(tx.QueryRow(ctx, stmt, args...))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// lockDocument блокирует строку документа до конца транзакции и проверяет,
+// что документ не захвачен на редактирование другим пользователем и, при
+// ненулевом ifVersion, что его версия не изменилась.
+func (repo *DocumentRepository) lockDocument(ctx context.Context, tx pgx.Tx, id, actorID string, ifVersion int64) (*domain.Document, error) {
+	doc, err := repo.selectForUpdate(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if ifVersion != 0 && doc.Version != ifVersion {
+		return nil, domain.ErrVersionMismatch
+	}
+
+	lock, err := repo.getLock(ctx, tx, id)
+	if errors.Is(err, domain.ErrNotFound) {
+		return doc, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if lock.UserID != actorID {
+		return nil, domain.ErrLocked
+	}
+	return doc, nil
+}
+
+func (repo *DocumentRepository) selectForUpdate(ctx context.Context, tx pgx.Tx, id string) (*domain.Document, error) {
+	stmt, args, err := repo.DialectWrapper.
+		Select(documentColumns...).
+		From("documents").
+		Where(goqu.Ex{"id": id}, notExpired()).
+		ForUpdate(exp.Wait).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	return scanDocument(tx.QueryRow(ctx, stmt, args...))
+}
+
+// AcquireLock захватывает документ или продлевает собственную блокировку.
+// Если документ захвачен другим пользователем, возвращает его блокировку
+// вместе с domain.ErrLocked.
+func (repo *DocumentRepository) AcquireLock(ctx context.Context, lock *domain.DocumentLock) (*domain.DocumentLock, error) {
+	stmt, args, err := repo.DialectWrapper.
+		Insert("document_locks").
+		Rows(goqu.Record{
+			"document_id": lock.DocumentID,
+			"user_id":     lock.UserID,
+			"acquired_at": goqu.L("NOW()"),
+			"expires_at":  lock.ExpiresAt,
+		}).
+		OnConflict(goqu.DoUpdate("document_id", goqu.Record{
+			"user_id": goqu.I("excluded.user_id"),
+			"acquired_at": goqu.Case().
+				When(goqu.I("document_locks.user_id").Eq(goqu.I("excluded.user_id")), goqu.I("document_locks.acquired_at")).
+				Else(goqu.I("excluded.acquired_at")),
+			"expires_at": goqu.I("excluded.expires_at"),
+		}).Where(goqu.Or(
+			goqu.I("document_locks.user_id").Eq(goqu.I("excluded.user_id")),
+			goqu.I("document_locks.expires_at").Lte(goqu.L("NOW()")),
+		))).
+		Returning("document_id", "user_id", "acquired_at", "expires_at").
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.Pool.Query(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	mdlLock, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.DocumentLock])
+	if errors.Is(err, pgx.ErrNoRows) {
+		held, err := repo.getLock(ctx, repo.Pool, lock.DocumentID)
+		if err != nil {
+			return nil, err
+		}
+		return held, domain.ErrLocked
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var acquired domain.DocumentLock
+	if err := mapping.MapStructModelToDomain(&mdlLock, &acquired); err != nil {
+		return nil, err
+	}
+	return &acquired, nil
+}
+
+func (repo *DocumentRepository) GetLock(ctx context.Context, documentID string) (*domain.DocumentLock, error) {
+	return repo.getLock(ctx, repo.Pool, documentID)
+}
+
+// ReleaseLock снимает блокировку пользователя; с force снимает любую.
+func (repo *DocumentRepository) ReleaseLock(ctx context.Context, documentID, userID string, force bool) error {
+	where := goqu.Ex{"document_id": documentID}
+	if !force {
+		where["user_id"] = userID
+	}
+
+	stmt, args, err := repo.DialectWrapper.
+		Delete("document_locks").
+		Where(where, goqu.C("expires_at").Gt(goqu.L("NOW()"))).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return err
+	}
+
+	tag, err := repo.Pool.Exec(ctx, stmt, args...)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (repo *DocumentRepository) getLock(ctx context.Context, q dbutils.Querier, documentID string) (*domain.DocumentLock, error) {
+	stmt, args, err := repo.DialectWrapper.
+		Select("document_id", "user_id", "acquired_at", "expires_at").
+		From("document_locks").
+		Where(
+			goqu.Ex{"document_id": documentID},
+			goqu.C("expires_at").Gt(goqu.L("NOW()")),
+		).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.Query(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	mdlLock, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.DocumentLock])
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var lock domain.DocumentLock
+	if err := mapping.MapStructModelToDomain(&mdlLock, &lock); err != nil {
+		return nil, err
+	}
+	return &lock, nil
+}
+
+func scanDocument(row pgx.Row) (*domain.Document, error) {
+	var mdlDoc models.Document
+	if err := row.Scan(
+		&mdlDoc.ID,
+		&mdlDoc.FileName,
+		&mdlDoc.MimeType,
+		&mdlDoc.HasFile,
+		&mdlDoc.IsPublic,
+		&mdlDoc.OwnerID,
+		&mdlDoc.Size,
+		&mdlDoc.ScanStatus,
+		&mdlDoc.ScanSignature,
+		&mdlDoc.Quarantined,
+		&mdlDoc.ExpiresAt,
+		&mdlDoc.CreatedAt,
+		&mdlDoc.UpdatedAt,
+		&mdlDoc.Checksum,
+		&mdlDoc.Version,
+	); err != nil {
+		return nil, err
+	}
+
+	var doc domain.Document
+	if err := mapping.MapStructModelToDomain(&mdlDoc, &doc); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+func notExpired() goqu.Expression {
+	return goqu.Or(
+		goqu.C("expires_at").IsNull(),
+		goqu.C("expires_at").Gt(goqu.L("NOW()")),
+	)
 }
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/DENFNC/web-test/internal/domain"
+	"github.com/DENFNC/web-test/internal/models"
+	"github.com/doug-martin/goqu/v9"
+	_ "github.com/doug-martin/goqu/v9/dialect/postgres"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type EventRepository struct {
+	*slog.Logger
+	*goqu.DialectWrapper
+	*pgxpool.Pool
+}
+
+func NewEventRepository(log *slog.Logger, pool *pgxpool.Pool) *EventRepository {
+	dialect := goqu.Dialect("postgres")
+	return &EventRepository{
+		Logger:         log,
+		DialectWrapper: &dialect,
+		Pool:           pool,
+	}
+}
+
+func (repo *EventRepository) SaveUserEvents(ctx context.Context, event *domain.DocumentEvent, recipients []string) error {
+	rows := make([]any, 0, len(recipients))
+	for _, recipientID := range recipients {
+		record := goqu.Record{
+			"recipient_id": recipientID,
+			"event_type":   event.Type,
+			"document_id":  event.DocumentID,
+			"owner_id":     event.OwnerID,
+			"user_id":      nil,
+			"occurred_at":  event.OccurredAt,
+		}
+		if event.UserID != "" {
+			record["user_id"] = event.UserID
+		}
+		rows = append(rows, record)
+	}
+
+	stmt, args, err := repo.DialectWrapper.
+		Insert("user_events").
+		Rows(rows...).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return err
+	}
+	_, err = repo.Pool.Exec(ctx, stmt, args...)
+	return err
+}
+
+func (repo *EventRepository) ListUserEvents(ctx context.Context, recipientID string, afterID int64, limit uint) ([]*domain.UserEvent, error) {
+	stmt, args, err := repo.DialectWrapper.
+		Select("id", "recipient_id", "event_type", "document_id", "owner_id", "user_id", "occurred_at").
+		From("user_events").
+		Where(
+			goqu.Ex{"recipient_id": recipientID},
+			goqu.C("id").Gt(afterID),
+		).
+		Order(goqu.C("id").Asc()).
+		Limit(limit).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.Pool.Query(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	mdlEvents, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.UserEvent])
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]*domain.UserEvent, 0, len(mdlEvents))
+	for _, m := range mdlEvents {
+		event := &domain.UserEvent{
+			ID:          m.ID.Int64,
+			RecipientID: m.RecipientID.String(),
+			DocumentEvent: domain.DocumentEvent{
+				Type:       m.EventType.String,
+				DocumentID: m.DocumentID.String(),
+				OwnerID:    m.OwnerID.String(),
+				OccurredAt: m.OccurredAt.Time,
+			},
+		}
+		if m.UserID.Valid {
+			event.UserID = m.UserID.String()
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+func (repo *EventRepository) DeleteUserEventsBefore(ctx context.Context, before time.Time, limit uint) (int, error) {
+	old := repo.DialectWrapper.
+		Select("id").
+		From("user_events").
+		Where(goqu.C("occurred_at").Lt(before)).
+		Order(goqu.C("id").Asc()).
+		Limit(limit)
+
+	stmt, args, err := repo.DialectWrapper.
+		Delete("user_events").
+		Where(goqu.C("id").In(old)).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return 0, err
+	}
+
+	tag, err := repo.Pool.Exec(ctx, stmt, args...)
+	if err != nil {
+		return 0, err
+	}
+	return int(tag.RowsAffected()), nil
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/DENFNC/web-test/internal/domain"
+	"github.com/DENFNC/web-test/internal/models"
+	"github.com/DENFNC/web-test/internal/utils/dbutils"
+	"github.com/DENFNC/web-test/internal/utils/mapping"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var inviteColumns = []any{"id", "code_hash", "created_by", "used_by", "used_at", "expires_at", "created_at"}
+
+func (repo *AuthRepository) SaveInvite(ctx context.Context, invite *domain.Invite, codeHash string) error {
+	var mdlInvite models.Invite
+	if err := mapping.MapStructModel(invite, &mdlInvite); err != nil {
+		return err
+	}
+	mdlInvite.CodeHash.String, mdlInvite.CodeHash.Valid = codeHash, true
+
+	stmt, args, err := repo.DialectWrapper.
+		Insert("invites").
+		Rows(mdlInvite).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return err
+	}
+
+	_, err = repo.Pool.Exec(ctx, stmt, args...)
+	return err
+}
+
+func (repo *AuthRepository) ListInvites(ctx context.Context) ([]domain.Invite, error) {
+	stmt, args, err := repo.DialectWrapper.
+		Select(inviteColumns...).
+		From("invites").
+		Order(goqu.C("created_at").Desc()).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.Pool.Query(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	mdlInvites, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.Invite])
+	if err != nil {
+		return nil, err
+	}
+
+	invites := make([]domain.Invite, 0, len(mdlInvites))
+	for _, mdlInvite := range mdlInvites {
+		var invite domain.Invite
+		if err := mapping.MapStructModelToDomain(&mdlInvite, &invite); err != nil {
+			return nil, err
+		}
+		invites = append(invites, invite)
+	}
+	return invites, nil
+}
+
+// RevokeInvite удаляет неиспользованное приглашение; использованное
+// остаётся в истории, и для него возвращается ErrNotFound.
+func (repo *AuthRepository) RevokeInvite(ctx context.Context, id string) error {
+	stmt, args, err := repo.DialectWrapper.
+		Delete("invites").
+		Where(goqu.Ex{"id": id, "used_at": nil}).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return err
+	}
+
+	tag, err := repo.Pool.Exec(ctx, stmt, args...)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// SaveUserWithInvite создаёт пользователя и в той же транзакции гасит
+// приглашение. Если приглашение не найдено, использовано или истекло,
+// пользователь не создаётся и возвращается ErrInvalidInvite.
+func (repo *AuthRepository) SaveUserWithInvite(ctx context.Context, user *domain.User, codeHash string) (string, error) {
+	var mdlUser models.User
+	if err := mapping.MapStructModel(user, &mdlUser); err != nil {
+		return "", err
+	}
+
+	var login string
+	err := dbutils.WithTransaction(ctx, repo.Pool, func(tx pgx.Tx) error {
+		var err error
+		login, err = repo.insertUser(ctx, tx, &mdlUser)
+		if err != nil {
+			return err
+		}
+
+		stmt, args, err := repo.DialectWrapper.
+			Update("invites").
+			Set(goqu.Record{
+				"used_by": user.ID,
+				"used_at": goqu.L("NOW()"),
+			}).
+			Where(
+				goqu.Ex{"code_hash": codeHash, "used_at": nil},
+				goqu.C("expires_at").Gt(goqu.L("NOW()")),
+			).
+			Returning("id").
+			Prepared(true).
+			ToSQL()
+		if err != nil {
+			return err
+		}
+
+		var inviteID pgtype.UUID
+		err = tx.QueryRow(ctx, stmt, args...).Scan(&inviteID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.ErrInvalidInvite
+		}
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return login, nil
+}
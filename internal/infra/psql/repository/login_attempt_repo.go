@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/DENFNC/web-test/internal/domain"
+	"github.com/DENFNC/web-test/internal/models"
+	"github.com/DENFNC/web-test/internal/utils/dbutils"
+	"github.com/DENFNC/web-test/internal/utils/mapping"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/jackc/pgx/v5"
+)
+
+var loginAttemptColumns = []any{"key", "failures", "last_failure_at", "locked_until", "expires_at"}
+
+// GetLoginAttempts возвращает счётчик key; для отсутствующей или истёкшей
+// записи — нулевое значение.
+func (repo *AuthRepository) GetLoginAttempts(ctx context.Context, key string) (*domain.LoginAttempts, error) {
+	stmt, args, err := repo.DialectWrapper.
+		Select(loginAttemptColumns...).
+		From("login_attempts").
+		Where(
+			goqu.Ex{"key": key},
+			goqu.C("expires_at").Gt(goqu.L("NOW()")),
+		).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.Pool.Query(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	mdlAttempts, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.LoginAttempts])
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &domain.LoginAttempts{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var attempts domain.LoginAttempts
+	if err := mapping.MapStructModelToDomain(&mdlAttempts, &attempts); err != nil {
+		return nil, err
+	}
+	return &attempts, nil
+}
+
+// UpdateLoginAttempts меняет счётчик key под блокировкой строки, так что
+// одновременные неудачные входы не теряются. fn получает текущее значение
+// (нулевое для истёкшей записи); запись без ExpiresAt считается истёкшей.
+func (repo *AuthRepository) UpdateLoginAttempts(ctx context.Context, key string, fn func(attempts *domain.LoginAttempts)) (*domain.LoginAttempts, error) {
+	var attempts domain.LoginAttempts
+	err := dbutils.WithTransaction(ctx, repo.Pool, func(tx pgx.Tx) error {
+		stmt, args, err := repo.DialectWrapper.
+			Insert("login_attempts").
+			Rows(goqu.Record{
+				"key":        key,
+				"expires_at": goqu.L("NOW()"),
+			}).
+			OnConflict(goqu.DoNothing()).
+			Prepared(true).
+			ToSQL()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, stmt, args...); err != nil {
+			return err
+		}
+
+		stmt, args, err = repo.DialectWrapper.
+			Select(loginAttemptColumns...).
+			From("login_attempts").
+			Where(goqu.Ex{"key": key}).
+			ForUpdate(exp.Wait).
+			Prepared(true).
+			ToSQL()
+		if err != nil {
+			return err
+		}
+
+		rows, err := tx.Query(ctx, stmt, args...)
+		if err != nil {
+			return err
+		}
+		mdlAttempts, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.LoginAttempts])
+		if err != nil {
+			return err
+		}
+
+		if mdlAttempts.ExpiresAt.Time.After(time.Now()) {
+			if err := mapping.MapStructModelToDomain(&mdlAttempts, &attempts); err != nil {
+				return err
+			}
+		}
+
+		fn(&attempts)
+
+		if err := mapping.MapStructModel(&attempts, &mdlAttempts); err != nil {
+			return err
+		}
+
+		var expiresAt any = mdlAttempts.ExpiresAt
+		if !mdlAttempts.ExpiresAt.Valid {
+			expiresAt = goqu.L("NOW()")
+		}
+
+		stmt, args, err = repo.DialectWrapper.
+			Update("login_attempts").
+			Set(goqu.Record{
+				"failures":        mdlAttempts.Failures,
+				"last_failure_at": mdlAttempts.LastFailureAt,
+				"locked_until":    mdlAttempts.LockedUntil,
+				"expires_at":      expiresAt,
+			}).
+			Where(goqu.Ex{"key": key}).
+			Prepared(true).
+			ToSQL()
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, stmt, args...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &attempts, nil
+}
+
+func (repo *AuthRepository) ResetLoginAttempts(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	stmt, args, err := repo.DialectWrapper.
+		Delete("login_attempts").
+		Where(goqu.Ex{"key": keys}).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return err
+	}
+
+	_, err = repo.Pool.Exec(ctx, stmt, args...)
+	return err
+}
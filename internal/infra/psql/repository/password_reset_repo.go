@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"time"
+
+	"github.com/DENFNC/web-test/internal/domain"
+	"github.com/DENFNC/web-test/internal/models"
+	"github.com/DENFNC/web-test/internal/utils/dbutils"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/jackc/pgx/v5"
+)
+
+// SavePasswordReset сохраняет код восстановления, заменяя прежний.
+func (repo *AuthRepository) SavePasswordReset(ctx context.Context, userID, codeHash string, expiresAt time.Time) error {
+	stmt, args, err := repo.DialectWrapper.
+		Insert("password_resets").
+		Rows(goqu.Record{
+			"user_id":    userID,
+			"code_hash":  codeHash,
+			"expires_at": expiresAt,
+		}).
+		OnConflict(goqu.DoUpdate("user_id", goqu.Record{
+			"code_hash":  goqu.I("excluded.code_hash"),
+			"expires_at": goqu.I("excluded.expires_at"),
+			"attempts":   0,
+			"created_at": goqu.L("NOW()"),
+		})).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return err
+	}
+
+	_, err = repo.Pool.Exec(ctx, stmt, args...)
+	return err
+}
+
+// ResetPassword проверяет код и, если он верен, в одной транзакции меняет
+// пароль, гасит код и отзывает все токены пользователя. Неверная попытка
+// засчитывается; после maxAttempts код перестаёт приниматься.
+func (repo *AuthRepository) ResetPassword(ctx context.Context, userID, codeHash, passwordHash string, maxAttempts int32) ([]string, error) {
+	var (
+		revoked []string
+		valid   bool
+	)
+	err := dbutils.WithTransaction(ctx, repo.Pool, func(tx pgx.Tx) error {
+		stmt, args, err := repo.DialectWrapper.
+			Select("user_id", "code_hash", "attempts", "expires_at", "created_at").
+			From("password_resets").
+			Where(goqu.Ex{"user_id": userID}).
+			ForUpdate(exp.Wait).
+			Prepared(true).
+			ToSQL()
+		if err != nil {
+			return err
+		}
+
+		rows, err := tx.Query(ctx, stmt, args...)
+		if err != nil {
+			return err
+		}
+		reset, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.PasswordReset])
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.ErrInvalidResetCode
+		}
+		if err != nil {
+			return err
+		}
+
+		if reset.Attempts.Int32 >= maxAttempts || !reset.ExpiresAt.Time.After(time.Now()) {
+			return domain.ErrInvalidResetCode
+		}
+
+		if subtle.ConstantTimeCompare([]byte(reset.CodeHash.String), []byte(codeHash)) != 1 {
+			stmt, args, err = repo.DialectWrapper.
+				Update("password_resets").
+				Set(goqu.Record{"attempts": goqu.L("attempts + 1")}).
+				Where(goqu.Ex{"user_id": userID}).
+				Prepared(true).
+				ToSQL()
+			if err != nil {
+				return err
+			}
+			_, err = tx.Exec(ctx, stmt, args...)
+			return err
+		}
+		valid = true
+
+		if err := repo.updatePassword(ctx, tx, userID, passwordHash); err != nil {
+			return err
+		}
+
+		stmt, args, err = repo.DialectWrapper.
+			Delete("password_resets").
+			Where(goqu.Ex{"user_id": userID}).
+			Prepared(true).
+			ToSQL()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, stmt, args...); err != nil {
+			return err
+		}
+
+		revoked, err = repo.revokeTokens(ctx, tx, userID, "")
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		return nil, domain.ErrInvalidResetCode
+	}
+	return revoked, nil
+}
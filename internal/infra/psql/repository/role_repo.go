@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/DENFNC/web-test/internal/domain"
+	"github.com/doug-martin/goqu/v9"
+)
+
+func (repo *AuthRepository) GetUserRole(ctx context.Context, userID string) (string, error) {
+	stmt, args, err := repo.DialectWrapper.
+		Select("role").
+		From("users").
+		Where(goqu.Ex{"id": userID}).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return "", err
+	}
+
+	var role string
+	if err := repo.Pool.QueryRow(ctx, stmt, args...).Scan(&role); err != nil {
+		return "", err
+	}
+	return role, nil
+}
+
+// SetUserRole возвращает ErrNotFound, если пользователя нет.
+func (repo *AuthRepository) SetUserRole(ctx context.Context, userID, role string) error {
+	stmt, args, err := repo.DialectWrapper.
+		Update("users").
+		Set(goqu.Record{"role": role}).
+		Where(goqu.Ex{"id": userID}).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return err
+	}
+
+	tag, err := repo.Pool.Exec(ctx, stmt, args...)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
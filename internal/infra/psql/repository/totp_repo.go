@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/DENFNC/web-test/internal/domain"
+	"github.com/DENFNC/web-test/internal/models"
+	"github.com/DENFNC/web-test/internal/utils/dbutils"
+	"github.com/DENFNC/web-test/internal/utils/mapping"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/jackc/pgx/v5"
+)
+
+func (repo *AuthRepository) GetTOTP(ctx context.Context, userID string) (*domain.TOTP, error) {
+	stmt, args, err := repo.DialectWrapper.
+		Select("user_id", "secret", "last_step", "enabled_at", "created_at").
+		From("user_totp").
+		Where(goqu.Ex{"user_id": userID}).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.Pool.Query(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	mdlTOTP, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.TOTP])
+	if err != nil {
+		return nil, err
+	}
+
+	var totp domain.TOTP
+	if err := mapping.MapStructModelToDomain(&mdlTOTP, &totp); err != nil {
+		return nil, err
+	}
+	return &totp, nil
+}
+
+// SaveTOTPSecret сохраняет неподтверждённый секрет, заменяя прежний
+// неподтверждённый. Если второй фактор уже включён, возвращает
+// ErrTOTPEnabled.
+func (repo *AuthRepository) SaveTOTPSecret(ctx context.Context, userID, secret string) error {
+	stmt, args, err := repo.DialectWrapper.
+		Insert("user_totp").
+		Rows(goqu.Record{
+			"user_id": userID,
+			"secret":  secret,
+		}).
+		OnConflict(goqu.DoUpdate("user_id", goqu.Record{
+			"secret":     goqu.I("excluded.secret"),
+			"last_step":  0,
+			"created_at": goqu.L("NOW()"),
+		}).Where(goqu.Ex{"user_totp.enabled_at": nil})).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return err
+	}
+
+	tag, err := repo.Pool.Exec(ctx, stmt, args...)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrTOTPEnabled
+	}
+	return nil
+}
+
+// EnableTOTP подтверждает секрет, запоминает шаг принятого кода и заменяет
+// резервные коды пользователя.
+func (repo *AuthRepository) EnableTOTP(ctx context.Context, userID string, step int64, recoveryCodeHashes []string) error {
+	return dbutils.WithTransaction(ctx, repo.Pool, func(tx pgx.Tx) error {
+		stmt, args, err := repo.DialectWrapper.
+			Update("user_totp").
+			Set(goqu.Record{
+				"enabled_at": goqu.L("NOW()"),
+				"last_step":  step,
+			}).
+			Where(goqu.Ex{"user_id": userID, "enabled_at": nil}).
+			Prepared(true).
+			ToSQL()
+		if err != nil {
+			return err
+		}
+
+		tag, err := tx.Exec(ctx, stmt, args...)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return domain.ErrNotFound
+		}
+
+		if err := repo.deleteRecoveryCodes(ctx, tx, userID); err != nil {
+			return err
+		}
+
+		if len(recoveryCodeHashes) == 0 {
+			return nil
+		}
+
+		records := make([]any, 0, len(recoveryCodeHashes))
+		for _, hash := range recoveryCodeHashes {
+			records = append(records, goqu.Record{
+				"user_id":   userID,
+				"code_hash": hash,
+			})
+		}
+
+		stmt, args, err = repo.DialectWrapper.
+			Insert("totp_recovery_codes").
+			Rows(records...).
+			Prepared(true).
+			ToSQL()
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, stmt, args...)
+		return err
+	})
+}
+
+// UseTOTPStep запоминает шаг принятого кода. false означает, что код этого
+// или более позднего шага уже использовался.
+func (repo *AuthRepository) UseTOTPStep(ctx context.Context, userID string, step int64) (bool, error) {
+	stmt, args, err := repo.DialectWrapper.
+		Update("user_totp").
+		Set(goqu.Record{"last_step": step}).
+		Where(
+			goqu.Ex{"user_id": userID},
+			goqu.C("enabled_at").IsNotNull(),
+			goqu.C("last_step").Lt(step),
+		).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return false, err
+	}
+
+	tag, err := repo.Pool.Exec(ctx, stmt, args...)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// UseRecoveryCode гасит резервный код; false — кода нет или он уже
+// использован.
+func (repo *AuthRepository) UseRecoveryCode(ctx context.Context, userID, codeHash string) (bool, error) {
+	stmt, args, err := repo.DialectWrapper.
+		Update("totp_recovery_codes").
+		Set(goqu.Record{"used_at": goqu.L("NOW()")}).
+		Where(goqu.Ex{
+			"user_id":   userID,
+			"code_hash": codeHash,
+			"used_at":   nil,
+		}).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return false, err
+	}
+
+	tag, err := repo.Pool.Exec(ctx, stmt, args...)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// DisableTOTP удаляет секрет и резервные коды. Если второй фактор не был
+// подключён, возвращает ErrNotFound.
+func (repo *AuthRepository) DisableTOTP(ctx context.Context, userID string) error {
+	return dbutils.WithTransaction(ctx, repo.Pool, func(tx pgx.Tx) error {
+		stmt, args, err := repo.DialectWrapper.
+			Delete("user_totp").
+			Where(goqu.Ex{"user_id": userID}).
+			Prepared(true).
+			ToSQL()
+		if err != nil {
+			return err
+		}
+
+		tag, err := tx.Exec(ctx, stmt, args...)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return domain.ErrNotFound
+		}
+
+		return repo.deleteRecoveryCodes(ctx, tx, userID)
+	})
+}
+
+func (repo *AuthRepository) deleteRecoveryCodes(ctx context.Context, tx pgx.Tx, userID string) error {
+	stmt, args, err := repo.DialectWrapper.
+		Delete("totp_recovery_codes").
+		Where(goqu.Ex{"user_id": userID}).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, stmt, args...)
+	return err
+}
@@ -0,0 +1,404 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/DENFNC/web-test/internal/domain"
+	"github.com/DENFNC/web-test/internal/models"
+	"github.com/DENFNC/web-test/internal/utils/dbutils"
+	"github.com/DENFNC/web-test/internal/utils/mapping"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/jackc/pgx/v5"
+)
+
+var transferColumns = []any{
+	"document_id",
+	"from_user_id",
+	"to_user_id",
+	"keep_access",
+	"created_at",
+}
+
+// CopyDocument создаёт копию документа для ownerID. Копия ссылается на тот
+// же файл; строка исходного документа блокируется FOR SHARE, чтобы файл не
+// удалили, пока копия не сохранена.
+func (repo *DocumentRepository) CopyDocument(ctx context.Context, srcID, newID, ownerID string, defaults domain.Quota) (*domain.Document, error) {
+	var doc *domain.Document
+	err := dbutils.WithTransaction(ctx, repo.Pool, func(tx pgx.Tx) error {
+		usage, err := repo.lockUsage(ctx, tx, ownerID, defaults)
+		if err != nil {
+			return err
+		}
+
+		stmt, args, err := repo.DialectWrapper.
+			Select(documentColumns...).
+			From("documents").
+			Where(goqu.Ex{"id": srcID}, notExpired()).
+			ForShare(exp.Wait).
+			Prepared(true).
+			ToSQL()
+		if err != nil {
+			return err
+		}
+
+		src, err := scanDocument(tx.QueryRow(ctx, stmt, args...))
+		if err != nil {
+			return err
+		}
+		if !usage.Allows(src.Size, 1) {
+			return domain.ErrQuotaExceeded
+		}
+
+		doc = &domain.Document{
+			ID:            newID,
+			FileName:      src.FileName,
+			MimeType:      src.MimeType,
+			HasFile:       src.HasFile,
+			OwnerID:       ownerID,
+			Size:          src.Size,
+			ScanStatus:    src.ScanStatus,
+			ScanSignature: src.ScanSignature,
+			Quarantined:   src.Quarantined,
+			Checksum:      src.Checksum,
+		}
+
+		var mdlDoc models.Document
+		if err := mapping.MapStructModel(doc, &mdlDoc); err != nil {
+			return err
+		}
+
+		stmt, args, err = repo.DialectWrapper.
+			Insert("documents").
+			Rows(mdlDoc).
+			Returning(documentColumns...).
+			Prepared(true).
+			ToSQL()
+		if err != nil {
+			return err
+		}
+
+		if doc, err = scanDocument(tx.QueryRow(ctx, stmt, args...)); err != nil {
+			return err
+		}
+
+		return repo.updateUsage(ctx, tx, ownerID, src.Size, 1)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// OfferTransfer сохраняет предложение передачи, заменяя предыдущее.
+func (repo *DocumentRepository) OfferTransfer(ctx context.Context, transfer *domain.DocumentTransfer) (*domain.DocumentTransfer, error) {
+	stmt, args, err := repo.DialectWrapper.
+		Insert("document_transfers").
+		Rows(goqu.Record{
+			"document_id":  transfer.DocumentID,
+			"from_user_id": transfer.FromUserID,
+			"to_user_id":   transfer.ToUserID,
+			"keep_access":  transfer.KeepAccess,
+		}).
+		OnConflict(goqu.DoUpdate("document_id", goqu.Record{
+			"from_user_id": goqu.I("excluded.from_user_id"),
+			"to_user_id":   goqu.I("excluded.to_user_id"),
+			"keep_access":  goqu.I("excluded.keep_access"),
+			"created_at":   goqu.L("NOW()"),
+		})).
+		Returning(transferColumns...).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.Pool.Query(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	return collectTransfer(rows)
+}
+
+func (repo *DocumentRepository) GetTransfer(ctx context.Context, documentID string) (*domain.DocumentTransfer, error) {
+	stmt, args, err := repo.DialectWrapper.
+		Select(transferColumns...).
+		From("document_transfers").
+		Where(goqu.Ex{"document_id": documentID}).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.Pool.Query(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	return collectTransfer(rows)
+}
+
+func (repo *DocumentRepository) ListIncomingTransfers(ctx context.Context, userID string) ([]*domain.DocumentTransfer, error) {
+	stmt, args, err := repo.DialectWrapper.
+		Select(transferColumns...).
+		From("document_transfers").
+		Where(goqu.Ex{"to_user_id": userID}).
+		Order(goqu.C("created_at").Desc()).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.Pool.Query(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	mdlTransfers, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.DocumentTransfer])
+	if err != nil {
+		return nil, err
+	}
+
+	transfers := make([]*domain.DocumentTransfer, 0, len(mdlTransfers))
+	for i := range mdlTransfers {
+		var transfer domain.DocumentTransfer
+		if err := mapping.MapStructModelToDomain(&mdlTransfers[i], &transfer); err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, &transfer)
+	}
+	return transfers, nil
+}
+
+// CancelTransfer удаляет предложение: отправитель его отзывает, получатель
+// отклоняет.
+func (repo *DocumentRepository) CancelTransfer(ctx context.Context, documentID, userID string) error {
+	stmt, args, err := repo.DialectWrapper.
+		Delete("document_transfers").
+		Where(
+			goqu.Ex{"document_id": documentID},
+			goqu.Or(
+				goqu.C("from_user_id").Eq(userID),
+				goqu.C("to_user_id").Eq(userID),
+			),
+		).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return err
+	}
+
+	tag, err := repo.Pool.Exec(ctx, stmt, args...)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// AcceptTransfer передаёт документ пользователю, которому он был предложен,
+// и возвращает его вместе с прежним владельцем. Если владелец сменился после
+// предложения, предложение считается недействительным.
+func (repo *DocumentRepository) AcceptTransfer(ctx context.Context, documentID, userID string, defaults domain.Quota) (*domain.Document, string, error) {
+	var (
+		doc  *domain.Document
+		from string
+	)
+	err := dbutils.WithTransaction(ctx, repo.Pool, func(tx pgx.Tx) error {
+		stmt, args, err := repo.DialectWrapper.
+			Delete("document_transfers").
+			Where(goqu.Ex{"document_id": documentID, "to_user_id": userID}).
+			Returning(transferColumns...).
+			Prepared(true).
+			ToSQL()
+		if err != nil {
+			return err
+		}
+
+		rows, err := tx.Query(ctx, stmt, args...)
+		if err != nil {
+			return err
+		}
+		transfer, err := collectTransfer(rows)
+		if err != nil {
+			return err
+		}
+
+		doc, from, err = repo.transferOwnership(ctx, tx, transfer, defaults)
+		return err
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return doc, from, nil
+}
+
+// ForceTransfer передаёт документ без согласия получателя и отменяет
+// ожидающее предложение.
+func (repo *DocumentRepository) ForceTransfer(ctx context.Context, documentID, toUserID string, keepAccess bool, defaults domain.Quota) (*domain.Document, string, error) {
+	var (
+		doc  *domain.Document
+		from string
+	)
+	err := dbutils.WithTransaction(ctx, repo.Pool, func(tx pgx.Tx) error {
+		stmt, args, err := repo.DialectWrapper.
+			Delete("document_transfers").
+			Where(goqu.Ex{"document_id": documentID}).
+			Prepared(true).
+			ToSQL()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, stmt, args...); err != nil {
+			return err
+		}
+
+		doc, from, err = repo.transferOwnership(ctx, tx, &domain.DocumentTransfer{
+			DocumentID: documentID,
+			ToUserID:   toUserID,
+			KeepAccess: keepAccess,
+		}, defaults)
+		return err
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return doc, from, nil
+}
+
+// transferOwnership меняет владельца, переносит учёт квоты и обновляет
+// доступы: у нового владельца строка доступа больше не нужна, прежний
+// сохраняет доступ только с KeepAccess. Пустой FromUserID означает текущего
+// владельца. Возвращает документ и прежнего владельца.
+func (repo *DocumentRepository) transferOwnership(ctx context.Context, tx pgx.Tx, transfer *domain.DocumentTransfer, defaults domain.Quota) (*domain.Document, string, error) {
+	stmt, args, err := repo.DialectWrapper.
+		Select("owner_id").
+		From("documents").
+		Where(goqu.Ex{"id": transfer.DocumentID}, notExpired()).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return nil, "", err
+	}
+
+	var from string
+	if err := tx.QueryRow(ctx, stmt, args...).Scan(&from); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, "", domain.ErrNotFound
+		}
+		return nil, "", err
+	}
+	if transfer.FromUserID != "" && transfer.FromUserID != from {
+		return nil, "", domain.ErrNotFound
+	}
+
+	// Учёт блокируется раньше документа, как при загрузке и замене файла,
+	// и в одном порядке, чтобы встречные передачи не приводили к
+	// взаимоблокировке.
+	first, second := from, transfer.ToUserID
+	if second < first {
+		first, second = second, first
+	}
+	usages := make(map[string]*domain.Usage, 2)
+	for _, userID := range []string{first, second} {
+		if usages[userID], err = repo.lockUsage(ctx, tx, userID, defaults); err != nil {
+			return nil, "", err
+		}
+	}
+
+	doc, err := repo.selectForUpdate(ctx, tx, transfer.DocumentID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, "", domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	if doc.OwnerID != from {
+		return nil, "", domain.ErrNotFound
+	}
+	if transfer.ToUserID == from {
+		return doc, from, nil
+	}
+
+	if !usages[transfer.ToUserID].Allows(doc.Size, 1) {
+		return nil, "", domain.ErrQuotaExceeded
+	}
+	if err := repo.updateUsage(ctx, tx, from, -doc.Size, -1); err != nil {
+		return nil, "", err
+	}
+	if err := repo.updateUsage(ctx, tx, transfer.ToUserID, doc.Size, 1); err != nil {
+		return nil, "", err
+	}
+
+	stmt, args, err = repo.DialectWrapper.
+		Update("documents").
+		Set(goqu.Record{
+			"owner_id":   transfer.ToUserID,
+			"version":    goqu.L("version + 1"),
+			"updated_at": goqu.L("NOW()"),
+		}).
+		Where(goqu.Ex{"id": doc.ID}).
+		Returning(documentColumns...).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return nil, "", err
+	}
+	if doc, err = scanDocument(tx.QueryRow(ctx, stmt, args...)); err != nil {
+		return nil, "", err
+	}
+
+	stmt, args, err = repo.DialectWrapper.
+		Delete("document_access").
+		Where(goqu.Ex{"document_id": doc.ID, "user_id": transfer.ToUserID}).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := tx.Exec(ctx, stmt, args...); err != nil {
+		return nil, "", err
+	}
+
+	if transfer.KeepAccess {
+		stmt, args, err = repo.DialectWrapper.
+			Insert("document_access").
+			Rows(goqu.Record{"document_id": doc.ID, "user_id": from}).
+			OnConflict(goqu.DoNothing()).
+			Prepared(true).
+			ToSQL()
+		if err != nil {
+			return nil, "", err
+		}
+		if _, err := tx.Exec(ctx, stmt, args...); err != nil {
+			return nil, "", err
+		}
+	}
+
+	access, err := repo.listAccess(ctx, tx, []string{doc.ID})
+	if err != nil {
+		return nil, "", err
+	}
+	doc.SharedWith = access[doc.ID]
+	return doc, from, nil
+}
+
+func collectTransfer(rows pgx.Rows) (*domain.DocumentTransfer, error) {
+	mdlTransfer, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.DocumentTransfer])
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var transfer domain.DocumentTransfer
+	if err := mapping.MapStructModelToDomain(&mdlTransfer, &transfer); err != nil {
+		return nil, err
+	}
+	return &transfer, nil
+}
@@ -0,0 +1,332 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/DENFNC/web-test/internal/domain"
+	"github.com/DENFNC/web-test/internal/models"
+	"github.com/DENFNC/web-test/internal/utils/dbutils"
+	"github.com/DENFNC/web-test/internal/utils/mapping"
+	"github.com/doug-martin/goqu/v9"
+	_ "github.com/doug-martin/goqu/v9/dialect/postgres"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type WebhookRepository struct {
+	*slog.Logger
+	*goqu.DialectWrapper
+	*pgxpool.Pool
+}
+
+func NewWebhookRepository(log *slog.Logger, pool *pgxpool.Pool) *WebhookRepository {
+	dialect := goqu.Dialect("postgres")
+	return &WebhookRepository{
+		Logger:         log,
+		DialectWrapper: &dialect,
+		Pool:           pool,
+	}
+}
+
+func (repo *WebhookRepository) SaveWebhook(ctx context.Context, hook *domain.Webhook) error {
+	var mdlHook models.Webhook
+	if err := mapping.MapStructModel(hook, &mdlHook); err != nil {
+		return err
+	}
+
+	return dbutils.WithTransaction(ctx, repo.Pool, func(tx pgx.Tx) error {
+		stmt, args, err := repo.DialectWrapper.
+			Insert("webhooks").
+			Rows(mdlHook).
+			Prepared(true).
+			ToSQL()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, stmt, args...); err != nil {
+			return err
+		}
+
+		subscriptions := make([]any, 0, len(hook.Events))
+		for _, event := range hook.Events {
+			subscriptions = append(subscriptions, goqu.Record{
+				"webhook_id": hook.ID,
+				"event_type": event,
+			})
+		}
+
+		stmt, args, err = repo.DialectWrapper.
+			Insert("webhook_subscriptions").
+			Rows(subscriptions...).
+			OnConflict(goqu.DoNothing()).
+			Prepared(true).
+			ToSQL()
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, stmt, args...)
+		return err
+	})
+}
+
+func (repo *WebhookRepository) ListWebhooks(ctx context.Context, ownerID string) ([]*domain.Webhook, error) {
+	return repo.listWebhooks(ctx, goqu.Ex{"w.owner_id": ownerID})
+}
+
+func (repo *WebhookRepository) GetWebhook(ctx context.Context, id, ownerID string) (*domain.Webhook, error) {
+	hooks, err := repo.listWebhooks(ctx, goqu.Ex{"w.id": id, "w.owner_id": ownerID})
+	if err != nil {
+		return nil, err
+	}
+	if len(hooks) == 0 {
+		return nil, domain.ErrNotFound
+	}
+	return hooks[0], nil
+}
+
+func (repo *WebhookRepository) listWebhooks(ctx context.Context, where goqu.Ex) ([]*domain.Webhook, error) {
+	stmt, args, err := repo.DialectWrapper.
+		Select(
+			goqu.I("w.id"),
+			goqu.I("w.owner_id"),
+			goqu.I("w.url"),
+			goqu.I("w.secret"),
+			goqu.I("w.is_active"),
+			goqu.I("w.created_at"),
+			goqu.L("COALESCE(ARRAY_AGG(s.event_type) FILTER (WHERE s.event_type IS NOT NULL), '{}')").As("events"),
+		).
+		From(goqu.T("webhooks").As("w")).
+		LeftJoin(
+			goqu.T("webhook_subscriptions").As("s"),
+			goqu.On(goqu.Ex{"s.webhook_id": goqu.I("w.id")}),
+		).
+		Where(where).
+		GroupBy(goqu.I("w.id")).
+		Order(goqu.I("w.created_at").Asc()).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.Pool.Query(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	mdlHooks, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.WebhookWithEvents])
+	if err != nil {
+		return nil, err
+	}
+
+	hooks := make([]*domain.Webhook, 0, len(mdlHooks))
+	for i := range mdlHooks {
+		var hook domain.Webhook
+		if err := mapping.MapStructModelToDomain(&mdlHooks[i].Webhook, &hook); err != nil {
+			return nil, err
+		}
+		hook.Events = mdlHooks[i].Events
+		hooks = append(hooks, &hook)
+	}
+	return hooks, nil
+}
+
+func (repo *WebhookRepository) DeleteWebhook(ctx context.Context, id, ownerID string) error {
+	stmt, args, err := repo.DialectWrapper.
+		Delete("webhooks").
+		Where(goqu.Ex{"id": id, "owner_id": ownerID}).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return err
+	}
+
+	tag, err := repo.Pool.Exec(ctx, stmt, args...)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// EnqueueDeliveries ставит событие в очередь для всех активных вебхуков
+// получателей, подписанных на этот тип событий.
+func (repo *WebhookRepository) EnqueueDeliveries(ctx context.Context, eventType string, userIDs []string, payload []byte) error {
+	subscribed := repo.DialectWrapper.
+		Select(
+			goqu.I("w.id"),
+			goqu.L("?::text", eventType),
+			goqu.L("?::jsonb", string(payload)),
+		).
+		From(goqu.T("webhooks").As("w")).
+		Join(
+			goqu.T("webhook_subscriptions").As("s"),
+			goqu.On(goqu.Ex{"s.webhook_id": goqu.I("w.id")}),
+		).
+		Where(goqu.Ex{
+			"w.is_active":  true,
+			"w.owner_id":   userIDs,
+			"s.event_type": eventType,
+		})
+
+	stmt, args, err := repo.DialectWrapper.
+		Insert("webhook_deliveries").
+		Cols("webhook_id", "event_type", "payload").
+		FromQuery(subscribed).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return err
+	}
+
+	_, err = repo.Pool.Exec(ctx, stmt, args...)
+	return err
+}
+
+// ClaimDeliveries захватывает готовые к отправке доставки и сдвигает их
+// следующую попытку на lease вперёд. Если обработчик упадёт, не записав
+// результат, доставка снова станет доступной после истечения lease.
+func (repo *WebhookRepository) ClaimDeliveries(ctx context.Context, limit uint, lease time.Duration) ([]*domain.DeliveryTask, error) {
+	due := repo.DialectWrapper.
+		Select("id").
+		From("webhook_deliveries").
+		Where(
+			goqu.Ex{"status": domain.DeliveryPending},
+			goqu.C("next_attempt_at").Lte(goqu.L("NOW()")),
+		).
+		Order(goqu.C("next_attempt_at").Asc()).
+		Limit(limit).
+		ForUpdate(exp.SkipLocked)
+
+	stmt, args, err := repo.DialectWrapper.
+		Update(goqu.T("webhook_deliveries").As("d")).
+		Set(goqu.Record{
+			"attempts":        goqu.L("d.attempts + 1"),
+			"next_attempt_at": goqu.L("NOW() + ? * INTERVAL '1 second'", lease.Seconds()),
+		}).
+		From(goqu.T("webhooks").As("w")).
+		Where(
+			goqu.Ex{"w.id": goqu.I("d.webhook_id")},
+			goqu.I("d.id").In(due),
+		).
+		Returning(
+			goqu.I("d.id"),
+			goqu.I("d.webhook_id"),
+			goqu.I("d.event_type"),
+			goqu.I("d.payload"),
+			goqu.I("d.attempts"),
+			goqu.I("w.url"),
+			goqu.I("w.secret"),
+		).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.Pool.Query(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	mdlTasks, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.DeliveryTask])
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := make([]*domain.DeliveryTask, 0, len(mdlTasks))
+	for i := range mdlTasks {
+		var task domain.DeliveryTask
+		if err := mapping.MapStructModelToDomain(&mdlTasks[i], &task.WebhookDelivery); err != nil {
+			return nil, err
+		}
+		task.URL = mdlTasks[i].URL.String
+		task.Secret = mdlTasks[i].Secret.String
+		tasks = append(tasks, &task)
+	}
+	return tasks, nil
+}
+
+func (repo *WebhookRepository) CompleteDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	record := goqu.Record{
+		"status":           delivery.Status,
+		"last_status_code": nullableInt4(delivery.LastStatusCode),
+		"last_error":       nullableText(delivery.LastError),
+		"next_attempt_at":  delivery.NextAttemptAt,
+	}
+	if delivery.Status == domain.DeliverySucceeded {
+		record["delivered_at"] = goqu.L("NOW()")
+	}
+
+	stmt, args, err := repo.DialectWrapper.
+		Update("webhook_deliveries").
+		Set(record).
+		Where(goqu.Ex{"id": delivery.ID}).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return err
+	}
+	_, err = repo.Pool.Exec(ctx, stmt, args...)
+	return err
+}
+
+func (repo *WebhookRepository) ListDeliveries(ctx context.Context, webhookID string, limit, offset uint) ([]*domain.WebhookDelivery, error) {
+	stmt, args, err := repo.DialectWrapper.
+		Select(
+			"id", "webhook_id", "event_type", "payload", "status", "attempts", "next_attempt_at",
+			"last_status_code", "last_error", "created_at", "delivered_at",
+		).
+		From("webhook_deliveries").
+		Where(goqu.Ex{"webhook_id": webhookID}).
+		Order(goqu.C("id").Desc()).
+		Limit(limit).
+		Offset(offset).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.Pool.Query(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	mdlDeliveries, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.WebhookDelivery])
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := make([]*domain.WebhookDelivery, 0, len(mdlDeliveries))
+	for i := range mdlDeliveries {
+		var delivery domain.WebhookDelivery
+		if err := mapping.MapStructModelToDomain(&mdlDeliveries[i], &delivery); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, &delivery)
+	}
+	return deliveries, nil
+}
+
+func nullableInt4(v int32) any {
+	if v == 0 {
+		return nil
+	}
+	return v
+}
+
+func nullableText(v string) any {
+	if v == "" {
+		return nil
+	}
+	return v
+}
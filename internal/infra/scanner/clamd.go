@@ -0,0 +1,116 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/DENFNC/web-test/internal/domain"
+)
+
+const defaultChunkSize = 64 << 10
+
+var ErrUnexpectedReply = errors.New("unexpected clamd reply")
+
+// ClamdScanner проверяет данные через clamd по протоколу INSTREAM:
+// команда zINSTREAM, затем чанки с 4-байтовой длиной в big-endian и
+// завершающий чанк нулевой длины.
+type ClamdScanner struct {
+	addr      string
+	timeout   time.Duration
+	chunkSize int
+}
+
+func NewClamdScanner(addr string, timeout time.Duration) *ClamdScanner {
+	return &ClamdScanner{
+		addr:      addr,
+		timeout:   timeout,
+		chunkSize: defaultChunkSize,
+	}
+}
+
+func (s *ClamdScanner) Scan(ctx context.Context, r io.Reader) (*domain.ScanResult, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(s.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return nil, err
+	}
+
+	if err := s.stream(conn, r); err != nil {
+		return nil, err
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	return parseReply(reply)
+}
+
+func (s *ClamdScanner) stream(w io.Writer, r io.Reader) error {
+	buf := make([]byte, s.chunkSize)
+	size := make([]byte, 4)
+
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size, uint32(n))
+			if _, err := w.Write(size); err != nil {
+				return err
+			}
+			if _, err := w.Write(buf[:n]); err != nil {
+				return err
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := w.Write([]byte{0, 0, 0, 0})
+	return err
+}
+
+// parseReply разбирает ответы вида "stream: OK",
+// "stream: Eicar-Signature FOUND" и "... ERROR".
+func parseReply(reply string) (*domain.ScanResult, error) {
+	reply = strings.TrimSpace(strings.TrimRight(reply, "\x00"))
+	reply = strings.TrimPrefix(reply, "stream: ")
+
+	switch {
+	case reply == "OK":
+		return &domain.ScanResult{}, nil
+	case strings.HasSuffix(reply, " FOUND"):
+		return &domain.ScanResult{
+			Infected:  true,
+			Signature: strings.TrimSuffix(reply, " FOUND"),
+		}, nil
+	case strings.HasSuffix(reply, " ERROR"):
+		return nil, fmt.Errorf("clamd: %s", strings.TrimSuffix(reply, " ERROR"))
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnexpectedReply, reply)
+	}
+}
@@ -0,0 +1,144 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeClamd принимает одно соединение, разбирает команду zINSTREAM и чанки
+// и отвечает reply; с пустым reply молчит до конца теста. Полученные данные
+// отправляются в канал.
+func fakeClamd(t *testing.T, reply string) (string, <-chan []byte) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	t.Cleanup(func() {
+		close(done)
+		ln.Close()
+	})
+
+	received := make(chan []byte, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		command, err := r.ReadString(0)
+		if err != nil || command != "zINSTREAM\x00" {
+			received <- nil
+			return
+		}
+
+		var data []byte
+		size := make([]byte, 4)
+		for {
+			if _, err := io.ReadFull(r, size); err != nil {
+				received <- nil
+				return
+			}
+			n := binary.BigEndian.Uint32(size)
+			if n == 0 {
+				break
+			}
+			chunk := make([]byte, n)
+			if _, err := io.ReadFull(r, chunk); err != nil {
+				received <- nil
+				return
+			}
+			data = append(data, chunk...)
+		}
+		received <- data
+
+		if reply == "" {
+			<-done
+			return
+		}
+		conn.Write([]byte(reply + "\x00"))
+	}()
+
+	return ln.Addr().String(), received
+}
+
+func TestClamdScanner_Scan(t *testing.T) {
+	tests := []struct {
+		name      string
+		reply     string
+		infected  bool
+		signature string
+		wantErr   bool
+	}{
+		{name: "clean", reply: "stream: OK"},
+		{name: "infected", reply: "stream: Eicar-Signature FOUND", infected: true, signature: "Eicar-Signature"},
+		{name: "clamd error", reply: "INSTREAM size limit exceeded. ERROR", wantErr: true},
+		{name: "unexpected reply", reply: "PONG", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr, received := fakeClamd(t, tt.reply)
+
+			s := NewClamdScanner(addr, time.Second)
+			s.chunkSize = 4
+			content := "some document content"
+
+			result, err := s.Scan(context.Background(), strings.NewReader(content))
+			if got := <-received; !bytes.Equal(got, []byte(content)) {
+				t.Errorf("clamd received %q, want %q", got, content)
+			}
+
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Scan() = %+v, want error", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Scan() error = %v", err)
+			}
+			if result.Infected != tt.infected || result.Signature != tt.signature {
+				t.Errorf("Scan() = %+v, want infected %v signature %q", result, tt.infected, tt.signature)
+			}
+		})
+	}
+}
+
+func TestClamdScanner_Unreachable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	s := NewClamdScanner(addr, time.Second)
+	if _, err := s.Scan(context.Background(), strings.NewReader("data")); err == nil {
+		t.Fatal("Scan() error = nil, want connection error")
+	}
+}
+
+func TestClamdScanner_Timeout(t *testing.T) {
+	addr, _ := fakeClamd(t, "")
+
+	s := NewClamdScanner(addr, 100*time.Millisecond)
+	_, err := s.Scan(context.Background(), strings.NewReader("data"))
+
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Fatalf("Scan() error = %v, want timeout", err)
+	}
+}
@@ -0,0 +1,174 @@
+package signer
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/DENFNC/web-test/internal/domain"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var ErrUnknownKey = errors.New("unknown signing key id")
+
+type signingKey struct {
+	method  jwt.SigningMethod
+	private crypto.Signer
+}
+
+// Signer подписывает access-токены активным ключом. Остальные ключи
+// публикуются в JWKS, чтобы токены, выданные до ротации, оставались
+// проверяемыми до истечения срока.
+type Signer struct {
+	active   string
+	issuer   string
+	audience string
+	keys     map[string]signingKey
+}
+
+type accessClaims struct {
+	SessionID string `json:"sid,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// NewSigner загружает закрытые ключи Ed25519 или RSA из PEM-файлов; keyFiles
+// сопоставляет kid с путём к файлу.
+func NewSigner(keyFiles map[string]string, active, issuer, audience string) (*Signer, error) {
+	s := &Signer{
+		active:   active,
+		issuer:   issuer,
+		audience: audience,
+		keys:     make(map[string]signingKey, len(keyFiles)),
+	}
+
+	for id, path := range keyFiles {
+		if id == "" {
+			return nil, fmt.Errorf("empty signing key id for %q", path)
+		}
+
+		key, err := loadKey(path)
+		if err != nil {
+			return nil, fmt.Errorf("signing key %q: %w", id, err)
+		}
+		s.keys[id] = key
+	}
+
+	if _, ok := s.keys[active]; !ok {
+		return nil, fmt.Errorf("%w: active key %q", ErrUnknownKey, active)
+	}
+
+	return s, nil
+}
+
+func (s *Signer) Sign(claims domain.AccessClaims) (string, error) {
+	key := s.keys[s.active]
+
+	registered := jwt.RegisteredClaims{
+		ID:        claims.ID,
+		Issuer:    s.issuer,
+		Subject:   claims.Subject,
+		IssuedAt:  jwt.NewNumericDate(claims.IssuedAt),
+		NotBefore: jwt.NewNumericDate(claims.IssuedAt),
+		ExpiresAt: jwt.NewNumericDate(claims.ExpiresAt),
+	}
+	if s.audience != "" {
+		registered.Audience = jwt.ClaimStrings{s.audience}
+	}
+
+	token := jwt.NewWithClaims(key.method, accessClaims{
+		SessionID:        claims.SessionID,
+		RegisteredClaims: registered,
+	})
+	token.Header["kid"] = s.active
+
+	return token.SignedString(key.private)
+}
+
+// Claims проверяет подпись токена, выданного этим сервером, и возвращает
+// его содержимое. Срок действия не проверяется: метод нужен, чтобы узнать
+// jti и sid уже отозванного токена.
+func (s *Signer) Claims(token string) (domain.AccessClaims, error) {
+	var claims accessClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(t *jwt.Token) (any, error) {
+		id, _ := t.Header["kid"].(string)
+		key, ok := s.keys[id]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownKey, id)
+		}
+		if t.Method.Alg() != key.method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method %q", t.Method.Alg())
+		}
+		return key.private.Public(), nil
+	}, jwt.WithoutClaimsValidation())
+	if err != nil {
+		return domain.AccessClaims{}, err
+	}
+	if claims.ExpiresAt == nil || claims.IssuedAt == nil {
+		return domain.AccessClaims{}, errors.New("token has no iat or exp")
+	}
+
+	return domain.AccessClaims{
+		ID:        claims.ID,
+		Subject:   claims.Subject,
+		SessionID: claims.SessionID,
+		IssuedAt:  claims.IssuedAt.Time,
+		ExpiresAt: claims.ExpiresAt.Time,
+	}, nil
+}
+
+// PublicKeys возвращает открытые ключи, отсортированные по kid.
+func (s *Signer) PublicKeys() []domain.PublicKey {
+	keys := make([]domain.PublicKey, 0, len(s.keys))
+	for id, key := range s.keys {
+		keys = append(keys, domain.PublicKey{
+			ID:        id,
+			Algorithm: key.method.Alg(),
+			Key:       key.private.Public(),
+		})
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+	return keys
+}
+
+func loadKey(path string) (signingKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return signingKey{}, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return signingKey{}, errors.New("no PEM block found")
+	}
+
+	var parsed any
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return signingKey{}, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return signingKey{}, err
+	}
+
+	switch key := parsed.(type) {
+	case ed25519.PrivateKey:
+		return signingKey{method: jwt.SigningMethodEdDSA, private: key}, nil
+	case *rsa.PrivateKey:
+		if key.N.BitLen() < 2048 {
+			return signingKey{}, errors.New("RSA key must be at least 2048 bits")
+		}
+		return signingKey{method: jwt.SigningMethodRS256, private: key}, nil
+	default:
+		return signingKey{}, fmt.Errorf("unsupported key type %T", parsed)
+	}
+}
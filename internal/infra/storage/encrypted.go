@@ -0,0 +1,313 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+const (
+	dataKeySize      = 32
+	defaultChunkSize = 64 << 10
+	wrappedKeySize   = 12 + dataKeySize + 16
+	tagSize          = 16
+)
+
+var blobMagic = []byte("WTENC\x00\x01\x00")
+
+var ErrCorruptBlob = errors.New("corrupt encrypted blob")
+
+type Backend interface {
+	Save(name string, r io.Reader) (int64, error)
+	Open(name string) (io.ReadSeekCloser, error)
+	Remove(name string) error
+}
+
+// EncryptedStorage шифрует каждый блоб собственным ключом данных
+// (AES-256-GCM), обёрнутым мастер-ключом из Keyring.
+//
+// Формат блоба:
+//
+//	magic(8) | len(keyID)(1) | keyID | chunkSize(4) | wrappedKey(60) | chunks...
+//
+// Каждый чанк — это до chunkSize байт открытого текста плюс 16 байт тега.
+// Nonce чанка — его номер, последний чанк дополнительно помечается флагом,
+// поэтому обрезка файла по границе чанка обнаруживается при расшифровке.
+// Блобы без заголовка отдаются как есть: это файлы, загруженные до
+// включения шифрования.
+type EncryptedStorage struct {
+	backend   Backend
+	keys      *Keyring
+	chunkSize int
+}
+
+func NewEncryptedStorage(backend Backend, keys *Keyring) *EncryptedStorage {
+	return &EncryptedStorage{
+		backend:   backend,
+		keys:      keys,
+		chunkSize: defaultChunkSize,
+	}
+}
+
+func (s *EncryptedStorage) Save(name string, r io.Reader) (int64, error) {
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return 0, err
+	}
+
+	keyID, wrapped, err := s.keys.wrap(dataKey)
+	if err != nil {
+		return 0, err
+	}
+
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return 0, err
+	}
+
+	var header bytes.Buffer
+	header.Write(blobMagic)
+	header.WriteByte(byte(len(keyID)))
+	header.WriteString(keyID)
+	_ = binary.Write(&header, binary.BigEndian, uint32(s.chunkSize))
+	header.Write(wrapped)
+
+	enc := &encryptReader{
+		src:   bufio.NewReaderSize(r, s.chunkSize),
+		aead:  aead,
+		plain: make([]byte, s.chunkSize),
+		out:   header.Bytes(),
+	}
+
+	if _, err := s.backend.Save(name, enc); err != nil {
+		return 0, err
+	}
+
+	return enc.written, nil
+}
+
+func (s *EncryptedStorage) Open(name string) (io.ReadSeekCloser, error) {
+	src, err := s.backend.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	blob, err := s.openBlob(src)
+	if err != nil {
+		src.Close()
+		return nil, err
+	}
+
+	return blob, nil
+}
+
+func (s *EncryptedStorage) Remove(name string) error {
+	return s.backend.Remove(name)
+}
+
+func (s *EncryptedStorage) openBlob(src io.ReadSeekCloser) (io.ReadSeekCloser, error) {
+	magic := make([]byte, len(blobMagic))
+	if _, err := io.ReadFull(src, magic); err != nil || !bytes.Equal(magic, blobMagic) {
+		if _, err := src.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		return src, nil
+	}
+
+	var idLen [1]byte
+	if _, err := io.ReadFull(src, idLen[:]); err != nil {
+		return nil, ErrCorruptBlob
+	}
+
+	keyID := make([]byte, idLen[0])
+	if _, err := io.ReadFull(src, keyID); err != nil {
+		return nil, ErrCorruptBlob
+	}
+
+	var chunkSize uint32
+	if err := binary.Read(src, binary.BigEndian, &chunkSize); err != nil || chunkSize == 0 {
+		return nil, ErrCorruptBlob
+	}
+
+	wrapped := make([]byte, wrappedKeySize)
+	if _, err := io.ReadFull(src, wrapped); err != nil {
+		return nil, ErrCorruptBlob
+	}
+
+	dataKey, err := s.keys.unwrap(string(keyID), wrapped)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	dataOffset := int64(len(blobMagic) + 1 + len(keyID) + 4 + wrappedKeySize)
+
+	end, err := src.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	body := end - dataOffset
+	sealedChunk := int64(chunkSize) + tagSize
+	chunks := (body + sealedChunk - 1) / sealedChunk
+	if body < tagSize || body-chunks*tagSize < 0 {
+		return nil, ErrCorruptBlob
+	}
+
+	return &decryptReader{
+		src:        src,
+		aead:       aead,
+		chunkSize:  int64(chunkSize),
+		dataOffset: dataOffset,
+		chunks:     chunks,
+		size:       body - chunks*tagSize,
+		current:    -1,
+	}, nil
+}
+
+func chunkNonce(aead cipher.AEAD, index int64, final bool) []byte {
+	nonce := make([]byte, aead.NonceSize())
+	binary.BigEndian.PutUint64(nonce, uint64(index))
+	if final {
+		nonce[len(nonce)-1] = 1
+	}
+	return nonce
+}
+
+type encryptReader struct {
+	src     *bufio.Reader
+	aead    cipher.AEAD
+	plain   []byte
+	out     []byte
+	index   int64
+	written int64
+	done    bool
+}
+
+func (e *encryptReader) Read(p []byte) (int, error) {
+	for len(e.out) == 0 {
+		if e.done {
+			return 0, io.EOF
+		}
+		if err := e.sealNext(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, e.out)
+	e.out = e.out[n:]
+	return n, nil
+}
+
+func (e *encryptReader) sealNext() error {
+	n, err := io.ReadFull(e.src, e.plain)
+
+	final := false
+	switch {
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		final = true
+	case err != nil:
+		return err
+	default:
+		if _, err := e.src.Peek(1); errors.Is(err, io.EOF) {
+			final = true
+		} else if err != nil {
+			return err
+		}
+	}
+
+	e.out = e.aead.Seal(nil, chunkNonce(e.aead, e.index, final), e.plain[:n], nil)
+	e.index++
+	e.written += int64(n)
+	e.done = final
+
+	return nil
+}
+
+type decryptReader struct {
+	src        io.ReadSeekCloser
+	aead       cipher.AEAD
+	chunkSize  int64
+	dataOffset int64
+	chunks     int64
+	size       int64
+	pos        int64
+
+	current int64
+	plain   []byte
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	if d.pos >= d.size {
+		return 0, io.EOF
+	}
+
+	index := d.pos / d.chunkSize
+	if index != d.current {
+		if err := d.load(index); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, d.plain[d.pos-index*d.chunkSize:])
+	d.pos += int64(n)
+	return n, nil
+}
+
+func (d *decryptReader) load(index int64) error {
+	sealedChunk := d.chunkSize + tagSize
+	if _, err := d.src.Seek(d.dataOffset+index*sealedChunk, io.SeekStart); err != nil {
+		return err
+	}
+
+	sealedLen := sealedChunk
+	if index == d.chunks-1 {
+		sealedLen = d.size - index*d.chunkSize + tagSize
+	}
+
+	sealed := make([]byte, sealedLen)
+	if _, err := io.ReadFull(d.src, sealed); err != nil {
+		return ErrCorruptBlob
+	}
+
+	plain, err := d.aead.Open(sealed[:0], chunkNonce(d.aead, index, index == d.chunks-1), sealed, nil)
+	if err != nil {
+		return ErrCorruptBlob
+	}
+
+	d.current = index
+	d.plain = plain
+	return nil
+}
+
+func (d *decryptReader) Seek(offset int64, whence int) (int64, error) {
+	var pos int64
+	switch whence {
+	case io.SeekStart:
+		pos = offset
+	case io.SeekCurrent:
+		pos = d.pos + offset
+	case io.SeekEnd:
+		pos = d.size + offset
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if pos < 0 {
+		return 0, errors.New("negative position")
+	}
+
+	d.pos = pos
+	return pos, nil
+}
+
+func (d *decryptReader) Close() error {
+	return d.src.Close()
+}
@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+const masterKeySize = 32
+
+var ErrUnknownKey = errors.New("unknown master key id")
+
+// Keyring хранит мастер-ключи по идентификаторам. Новые блобы шифруются
+// активным ключом, остальные нужны для чтения блобов, созданных до ротации.
+type Keyring struct {
+	active string
+	keys   map[string]cipher.AEAD
+}
+
+func NewKeyring(keys map[string]string, active string) (*Keyring, error) {
+	ring := &Keyring{
+		active: active,
+		keys:   make(map[string]cipher.AEAD, len(keys)),
+	}
+
+	for id, encoded := range keys {
+		if len(id) == 0 || len(id) > 255 {
+			return nil, fmt.Errorf("invalid master key id %q", id)
+		}
+
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("master key %q: %w", id, err)
+		}
+		if len(key) != masterKeySize {
+			return nil, fmt.Errorf("master key %q must be %d bytes", id, masterKeySize)
+		}
+
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, err
+		}
+		ring.keys[id] = aead
+	}
+
+	if _, ok := ring.keys[active]; !ok {
+		return nil, fmt.Errorf("%w: active key %q", ErrUnknownKey, active)
+	}
+
+	return ring, nil
+}
+
+func (k *Keyring) wrap(dataKey []byte) (string, []byte, error) {
+	aead := k.keys[k.active]
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", nil, err
+	}
+
+	return k.active, aead.Seal(nonce, nonce, dataKey, []byte(k.active)), nil
+}
+
+func (k *Keyring) unwrap(id string, wrapped []byte) ([]byte, error) {
+	aead, ok := k.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, id)
+	}
+
+	if len(wrapped) < aead.NonceSize() {
+		return nil, ErrCorruptBlob
+	}
+	nonce, sealed := wrapped[:aead.NonceSize()], wrapped[aead.NonceSize():]
+
+	return aead.Open(nil, nonce, sealed, []byte(id))
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var ErrInvalidPath = errors.New("invalid file path")
+
+type LocalStorage struct {
+	root string
+}
+
+func NewLocalStorage(root string) (*LocalStorage, error) {
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, err
+	}
+
+	return &LocalStorage{
+		root: filepath.Clean(root),
+	}, nil
+}
+
+// Save пишет данные во временный файл и переименовывает его только после
+// успешной записи, чтобы читатели не видели частично записанный блоб.
+func (s *LocalStorage) Save(name string, r io.Reader) (int64, error) {
+	path, err := s.resolve(name)
+	if err != nil {
+		return 0, err
+	}
+
+	tmp, err := os.CreateTemp(s.root, ".upload-*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+
+	n, err := io.Copy(tmp, r)
+	if err != nil {
+		tmp.Close()
+		return 0, err
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, err
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
+
+func (s *LocalStorage) Open(name string) (io.ReadSeekCloser, error) {
+	path, err := s.resolve(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.Open(path)
+}
+
+func (s *LocalStorage) Remove(name string) error {
+	path, err := s.resolve(name)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+func (s *LocalStorage) resolve(name string) (string, error) {
+	path := filepath.Clean(filepath.Join(s.root, name))
+
+	rel, err := filepath.Rel(s.root, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return "", ErrInvalidPath
+	}
+
+	return path, nil
+}
@@ -0,0 +1,21 @@
+package models
+
+import "github.com/jackc/pgx/v5/pgtype"
+
+type APIKey struct {
+	ID         pgtype.UUID        `db:"id"`
+	UserID     pgtype.UUID        `db:"user_id"`
+	Name       pgtype.Text        `db:"name"`
+	KeyHash    pgtype.Text        `db:"key_hash"`
+	Prefix     pgtype.Text        `db:"prefix"`
+	ExpiresAt  pgtype.Timestamptz `db:"expires_at"`
+	LastUsedAt pgtype.Timestamptz `db:"last_used_at"`
+	LastUsedIP pgtype.Text        `db:"last_used_ip"`
+	CreatedAt  pgtype.Timestamptz `db:"created_at" goqu:"omitempty"`
+}
+
+type APIKeyWithScopes struct {
+	APIKey
+	Scopes          []string `db:"scopes"`
+	AllowedNetworks []string `db:"allowed_networks"`
+}
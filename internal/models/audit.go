@@ -0,0 +1,14 @@
+package models
+
+import "github.com/jackc/pgx/v5/pgtype"
+
+type AuditEvent struct {
+	ID         pgtype.Int8        `db:"id" goqu:"skipinsert"`
+	ActorID    pgtype.UUID        `db:"actor_id"`
+	DocumentID pgtype.UUID        `db:"document_id"`
+	TargetID   pgtype.UUID        `db:"target_id"`
+	Action     pgtype.Text        `db:"action"`
+	IP         pgtype.Text        `db:"ip"`
+	UserAgent  pgtype.Text        `db:"user_agent"`
+	CreatedAt  pgtype.Timestamptz `db:"created_at" goqu:"omitempty"`
+}
@@ -5,11 +5,19 @@ import (
 )
 
 type Document struct {
-	ID        pgtype.UUID        `db:"id"`
-	FileName  pgtype.Text        `db:"file_name"`
-	MimeType  pgtype.Text        `db:"mime_type"`
-	HasFile   pgtype.Bool        `db:"has_file"`
-	IsPublic  pgtype.Bool        `db:"is_public"`
-	OwnerID   pgtype.UUID        `db:"owner_id"`
-	CreatedAt pgtype.Timestamptz `db:"created_at" goqu:"omitempty"`
+	ID            pgtype.UUID        `db:"id"`
+	FileName      pgtype.Text        `db:"file_name"`
+	MimeType      pgtype.Text        `db:"mime_type"`
+	HasFile       pgtype.Bool        `db:"has_file"`
+	IsPublic      pgtype.Bool        `db:"is_public"`
+	OwnerID       pgtype.UUID        `db:"owner_id"`
+	Size          pgtype.Int8        `db:"size_bytes"`
+	ScanStatus    pgtype.Text        `db:"scan_status"`
+	ScanSignature pgtype.Text        `db:"scan_signature"`
+	Quarantined   pgtype.Bool        `db:"quarantined"`
+	ExpiresAt     pgtype.Timestamptz `db:"expires_at"`
+	CreatedAt     pgtype.Timestamptz `db:"created_at" goqu:"omitempty"`
+	UpdatedAt     pgtype.Timestamptz `db:"updated_at" goqu:"omitempty"`
+	Checksum      pgtype.Text        `db:"checksum_sha256"`
+	Version       pgtype.Int8        `db:"version" goqu:"skipinsert"`
 }
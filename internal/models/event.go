@@ -0,0 +1,13 @@
+package models
+
+import "github.com/jackc/pgx/v5/pgtype"
+
+type UserEvent struct {
+	ID          pgtype.Int8        `db:"id"`
+	RecipientID pgtype.UUID        `db:"recipient_id"`
+	EventType   pgtype.Text        `db:"event_type"`
+	DocumentID  pgtype.UUID        `db:"document_id"`
+	OwnerID     pgtype.UUID        `db:"owner_id"`
+	UserID      pgtype.UUID        `db:"user_id"`
+	OccurredAt  pgtype.Timestamptz `db:"occurred_at"`
+}
@@ -0,0 +1,10 @@
+package models
+
+import "github.com/jackc/pgx/v5/pgtype"
+
+type DocumentLock struct {
+	DocumentID pgtype.UUID        `db:"document_id"`
+	UserID     pgtype.UUID        `db:"user_id"`
+	AcquiredAt pgtype.Timestamptz `db:"acquired_at"`
+	ExpiresAt  pgtype.Timestamptz `db:"expires_at"`
+}
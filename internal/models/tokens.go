@@ -3,8 +3,61 @@ package models
 import "github.com/jackc/pgx/v5/pgtype"
 
 type AuthToken struct {
+	UserID     pgtype.UUID        `db:"user_id"`
+	Token      pgtype.Text        `db:"token"`
+	IsRevoked  pgtype.Bool        `db:"is_revoked" goqu:"omitempty"`
+	FamilyID   pgtype.UUID        `db:"family_id"`
+	ExpiresAt  pgtype.Timestamptz `db:"expires_at"`
+	LastUsedAt pgtype.Timestamptz `db:"last_used_at" goqu:"omitempty"`
+	IP         pgtype.Text        `db:"ip"`
+	UserAgent  pgtype.Text        `db:"user_agent"`
+	CreatedAt  pgtype.Timestamptz `db:"created_at" goqu:"omitempty"`
+}
+
+type Session struct {
+	ID         pgtype.UUID        `db:"id"`
+	CreatedAt  pgtype.Timestamptz `db:"created_at"`
+	LastUsedAt pgtype.Timestamptz `db:"last_used_at"`
+	IP         pgtype.Text        `db:"ip"`
+	UserAgent  pgtype.Text        `db:"user_agent"`
+	Current    pgtype.Bool        `db:"current"`
+}
+
+type RefreshToken struct {
+	TokenHash pgtype.Text        `db:"token_hash"`
+	UserID    pgtype.UUID        `db:"user_id"`
+	FamilyID  pgtype.UUID        `db:"family_id"`
+	ExpiresAt pgtype.Timestamptz `db:"expires_at"`
+	UsedAt    pgtype.Timestamptz `db:"used_at"`
+	IsRevoked pgtype.Bool        `db:"is_revoked"`
+}
+
+type RevokedToken struct {
+	ID        pgtype.Text        `db:"jti"`
+	SessionID pgtype.UUID        `db:"session_id"`
+	ExpiresAt pgtype.Timestamptz `db:"expires_at"`
+}
+
+type PasswordReset struct {
 	UserID    pgtype.UUID        `db:"user_id"`
-	Token     pgtype.Text        `db:"token"`
-	IsRevoked pgtype.Bool        `db:"is_revoked" goqu:"omitempty"`
-	CreatedAt pgtype.Timestamptz `db:"created_at" goqu:"omitempty"`
+	CodeHash  pgtype.Text        `db:"code_hash"`
+	Attempts  pgtype.Int4        `db:"attempts"`
+	ExpiresAt pgtype.Timestamptz `db:"expires_at"`
+	CreatedAt pgtype.Timestamptz `db:"created_at"`
+}
+
+type TOTP struct {
+	UserID    pgtype.UUID        `db:"user_id"`
+	Secret    pgtype.Text        `db:"secret"`
+	LastStep  pgtype.Int8        `db:"last_step"`
+	EnabledAt pgtype.Timestamptz `db:"enabled_at"`
+	CreatedAt pgtype.Timestamptz `db:"created_at"`
+}
+
+type LoginAttempts struct {
+	Key           pgtype.Text        `db:"key"`
+	Failures      pgtype.Int4        `db:"failures"`
+	LastFailureAt pgtype.Timestamptz `db:"last_failure_at"`
+	LockedUntil   pgtype.Timestamptz `db:"locked_until"`
+	ExpiresAt     pgtype.Timestamptz `db:"expires_at"`
 }
@@ -0,0 +1,11 @@
+package models
+
+import "github.com/jackc/pgx/v5/pgtype"
+
+type DocumentTransfer struct {
+	DocumentID pgtype.UUID        `db:"document_id"`
+	FromUserID pgtype.UUID        `db:"from_user_id"`
+	ToUserID   pgtype.UUID        `db:"to_user_id"`
+	KeepAccess pgtype.Bool        `db:"keep_access"`
+	CreatedAt  pgtype.Timestamptz `db:"created_at" goqu:"omitempty"`
+}
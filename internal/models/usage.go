@@ -0,0 +1,11 @@
+package models
+
+import "github.com/jackc/pgx/v5/pgtype"
+
+type UserUsage struct {
+	UserID         pgtype.UUID `db:"user_id"`
+	BytesUsed      pgtype.Int8 `db:"bytes_used"`
+	DocumentsCount pgtype.Int8 `db:"documents_count"`
+	QuotaBytes     pgtype.Int8 `db:"quota_bytes"`
+	QuotaDocuments pgtype.Int8 `db:"quota_documents"`
+}
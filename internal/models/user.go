@@ -8,10 +8,22 @@ type User struct {
 	ID        pgtype.UUID        `db:"id"`
 	Login     pgtype.Text        `db:"login"`
 	Password  pgtype.Text        `db:"password_hash"`
+	Email     pgtype.Text        `db:"email"`
+	Role      pgtype.Text        `db:"role" goqu:"omitempty"`
 	CreatedAt pgtype.Timestamptz `db:"created_at" goqu:"omitempty"`
 }
 
 type UserCredentials struct {
+	UserID   pgtype.UUID `db:"user_id"`
 	Password pgtype.Text `db:"password_hash"`
-	Token    pgtype.Text `db:"token"`
+}
+
+type Invite struct {
+	ID        pgtype.UUID        `db:"id"`
+	CodeHash  pgtype.Text        `db:"code_hash"`
+	CreatedBy pgtype.UUID        `db:"created_by"`
+	UsedBy    pgtype.UUID        `db:"used_by"`
+	UsedAt    pgtype.Timestamptz `db:"used_at"`
+	ExpiresAt pgtype.Timestamptz `db:"expires_at"`
+	CreatedAt pgtype.Timestamptz `db:"created_at" goqu:"omitempty"`
 }
@@ -0,0 +1,41 @@
+package models
+
+import "github.com/jackc/pgx/v5/pgtype"
+
+type Webhook struct {
+	ID        pgtype.UUID        `db:"id"`
+	OwnerID   pgtype.UUID        `db:"owner_id"`
+	URL       pgtype.Text        `db:"url"`
+	Secret    pgtype.Text        `db:"secret"`
+	IsActive  pgtype.Bool        `db:"is_active"`
+	CreatedAt pgtype.Timestamptz `db:"created_at" goqu:"omitempty"`
+}
+
+type WebhookWithEvents struct {
+	Webhook
+	Events []string `db:"events"`
+}
+
+type WebhookDelivery struct {
+	ID             pgtype.Int8        `db:"id"`
+	WebhookID      pgtype.UUID        `db:"webhook_id"`
+	EventType      pgtype.Text        `db:"event_type"`
+	Payload        []byte             `db:"payload"`
+	Status         pgtype.Text        `db:"status"`
+	Attempts       pgtype.Int4        `db:"attempts"`
+	NextAttemptAt  pgtype.Timestamptz `db:"next_attempt_at"`
+	LastStatusCode pgtype.Int4        `db:"last_status_code"`
+	LastError      pgtype.Text        `db:"last_error"`
+	CreatedAt      pgtype.Timestamptz `db:"created_at"`
+	DeliveredAt    pgtype.Timestamptz `db:"delivered_at"`
+}
+
+type DeliveryTask struct {
+	ID        pgtype.Int8 `db:"id"`
+	WebhookID pgtype.UUID `db:"webhook_id"`
+	EventType pgtype.Text `db:"event_type"`
+	Payload   []byte      `db:"payload"`
+	Attempts  pgtype.Int4 `db:"attempts"`
+	URL       pgtype.Text `db:"url"`
+	Secret    pgtype.Text `db:"secret"`
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/DENFNC/web-test/internal/domain"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	apiKeySize = 32
+	// apiKeyPrefixLength — сколько первых символов ключа хранится открыто.
+	apiKeyPrefixLength = len(domain.APIKeyPrefix) + 8
+)
+
+// CreateAPIKey выпускает ключ для пользователя userID; если его нет,
+// возвращается ErrNotFound. Адреса в AllowedNetworks приводятся к виду
+// сетей; сам ключ возвращается только здесь.
+func (srv *AuthService) CreateAPIKey(ctx context.Context, userID string, key *domain.APIKey) (*domain.APIKey, error) {
+	const op = "service.AuthService.CreateAPIKey"
+
+	log := srv.Logger.With("op", op)
+
+	scopes := slices.Clone(key.Scopes)
+	slices.Sort(scopes)
+	scopes = slices.Compact(scopes)
+	for _, scope := range scopes {
+		if !domain.ValidScope(scope) {
+			return nil, domain.ErrInvalidScope
+		}
+	}
+
+	networks := make([]string, 0, len(key.AllowedNetworks))
+	for _, network := range key.AllowedNetworks {
+		parsed, err := domain.ParseNetwork(network)
+		if err != nil {
+			return nil, err
+		}
+		networks = append(networks, parsed)
+	}
+
+	if _, err := srv.repo.GetUserByID(ctx, userID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+
+	buf := make([]byte, apiKeySize)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	secret := domain.APIKeyPrefix + base64.RawURLEncoding.EncodeToString(buf)
+
+	created := &domain.APIKey{
+		ID:              uuid.New().String(),
+		UserID:          userID,
+		Name:            key.Name,
+		Key:             secret,
+		Prefix:          secret[:apiKeyPrefixLength],
+		Scopes:          scopes,
+		AllowedNetworks: networks,
+		ExpiresAt:       key.ExpiresAt,
+		CreatedAt:       time.Now(),
+	}
+	if err := srv.repo.SaveAPIKey(ctx, created, hashCode(secret)); err != nil {
+		log.Error(
+			"Failed to save api key",
+			slog.String("err", err.Error()),
+		)
+		return nil, err
+	}
+
+	return created, nil
+}
+
+func (srv *AuthService) ListAPIKeys(ctx context.Context, userID string) ([]domain.APIKey, error) {
+	return srv.repo.ListAPIKeys(ctx, userID)
+}
+
+func (srv *AuthService) RevokeAPIKey(ctx context.Context, userID, id string) error {
+	return srv.repo.DeleteAPIKey(ctx, id, userID)
+}
+
+// ValidateAPIKey находит действующий ключ и проверяет адрес клиента.
+// Неизвестный и просроченный ключ дают ErrInvalidToken. Ключи не
+// кэшируются, поэтому отзыв действует сразу.
+func (srv *AuthService) ValidateAPIKey(ctx context.Context, secret, ip string) (*domain.APIKey, error) {
+	const op = "service.AuthService.ValidateAPIKey"
+
+	log := srv.Logger.With("op", op)
+
+	key, err := srv.repo.GetAPIKeyByHash(ctx, hashCode(secret))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !key.ExpiresAt.IsZero() && !key.ExpiresAt.After(time.Now()) {
+		return nil, domain.ErrInvalidToken
+	}
+	if !key.AllowsIP(ip) {
+		log.Warn(
+			"API key used from a disallowed address",
+			slog.String("key_id", key.ID),
+			slog.String("ip", ip),
+		)
+		return nil, domain.ErrAPIKeyIPNotAllowed
+	}
+
+	if err := srv.repo.TouchAPIKey(ctx, key.ID, ip); err != nil {
+		log.Error(
+			"Failed to record api key usage",
+			slog.String("err", err.Error()),
+		)
+	}
+
+	return key, nil
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/DENFNC/web-test/internal/domain"
+)
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+type AuditRepository interface {
+	SaveEvent(ctx context.Context, event *domain.AuditEvent) error
+	ListEvents(ctx context.Context, filter domain.AuditFilter) ([]*domain.AuditEvent, error)
+}
+
+type AuditService struct {
+	*slog.Logger
+	repo AuditRepository
+}
+
+func NewAuditService(log *slog.Logger, repo AuditRepository) *AuditService {
+	return &AuditService{
+		Logger: log,
+		repo:   repo,
+	}
+}
+
+// Record сохраняет событие, даже если запрос клиента уже отменён. Ошибка
+// записи журнала не прерывает основную операцию, а только логируется.
+func (srv *AuditService) Record(ctx context.Context, event *domain.AuditEvent) {
+	const op = "service.AuditService.Record"
+
+	log := srv.Logger.With("op", op)
+
+	if err := srv.repo.SaveEvent(context.WithoutCancel(ctx), event); err != nil {
+		log.Error(
+			"Failed to record audit event",
+			slog.String("action", event.Action),
+			slog.String("document_id", event.DocumentID),
+			slog.String("actor_id", event.ActorID),
+			slog.String("err", err.Error()),
+		)
+	}
+}
+
+func (srv *AuditService) ListEvents(ctx context.Context, filter domain.AuditFilter) ([]*domain.AuditEvent, error) {
+	switch {
+	case filter.Limit == 0:
+		filter.Limit = defaultAuditLimit
+	case filter.Limit > maxAuditLimit:
+		filter.Limit = maxAuditLimit
+	}
+
+	return srv.repo.ListEvents(ctx, filter)
+}
@@ -2,37 +2,115 @@ package service
 
 import (
 	"context"
+	"errors"
 	"log/slog"
+	"sync"
+	"time"
 
 	"github.com/DENFNC/web-test/internal/domain"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type AuthRepository interface {
-	GetByID(ctx context.Context, id string) (*domain.UserCredentials, error)
+	GetCredentialsByLogin(ctx context.Context, login string) (*domain.UserCredentials, error)
+	GetCredentialsByUserID(ctx context.Context, userID string) (*domain.UserCredentials, error)
+	ChangePassword(ctx context.Context, userID, passwordHash, currentToken string) ([]string, error)
+	GetUserByLogin(ctx context.Context, login string) (*domain.User, error)
+	GetUserByID(ctx context.Context, userID string) (*domain.User, error)
+	SavePasswordReset(ctx context.Context, userID, codeHash string, expiresAt time.Time) error
+	ResetPassword(ctx context.Context, userID, codeHash, passwordHash string, maxAttempts int32) ([]string, error)
+	GetTOTP(ctx context.Context, userID string) (*domain.TOTP, error)
+	SaveTOTPSecret(ctx context.Context, userID, secret string) error
+	EnableTOTP(ctx context.Context, userID string, step int64, recoveryCodeHashes []string) error
+	UseTOTPStep(ctx context.Context, userID string, step int64) (bool, error)
+	UseRecoveryCode(ctx context.Context, userID, codeHash string) (bool, error)
+	DisableTOTP(ctx context.Context, userID string) error
 	SaveUser(ctx context.Context, user *domain.User) (string, error)
-	RevokeToken(ctx context.Context, token string) error
+	SaveUserWithInvite(ctx context.Context, user *domain.User, codeHash string) (string, error)
+	SaveInvite(ctx context.Context, invite *domain.Invite, codeHash string) error
+	ListInvites(ctx context.Context) ([]domain.Invite, error)
+	RevokeInvite(ctx context.Context, id string) error
+	GetUserRole(ctx context.Context, userID string) (string, error)
+	SetUserRole(ctx context.Context, userID, role string) error
+	SaveAPIKey(ctx context.Context, key *domain.APIKey, keyHash string) error
+	ListAPIKeys(ctx context.Context, userID string) ([]domain.APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (*domain.APIKey, error)
+	DeleteAPIKey(ctx context.Context, id, userID string) error
+	TouchAPIKey(ctx context.Context, id, ip string) error
+	IssueTokens(ctx context.Context, userID string, ttl domain.TokenTTL, info domain.SessionInfo) (*domain.TokenPair, error)
+	RotateRefreshToken(ctx context.Context, refreshToken string, ttl domain.TokenTTL, info domain.SessionInfo) (*domain.TokenPair, []string, error)
+	ListSessions(ctx context.Context, userID, currentToken string) ([]domain.Session, error)
+	RevokeSession(ctx context.Context, userID, sessionID string) ([]string, error)
+	RevokeAllSessions(ctx context.Context, userID string) ([]string, error)
+	RevokeToken(ctx context.Context, userID, token string) ([]string, error)
+	DeleteExpiredTokens(ctx context.Context, limit uint) (int, error)
+	ListRevokedTokens(ctx context.Context) ([]domain.RevokedToken, error)
 }
 
+const passwordCost = 14
+
 type AuthService struct {
 	*slog.Logger
-	repo AuthRepository
+	repo   AuthRepository
+	ttl    domain.TokenTTL
+	caches []Cache
+
+	notifier Notifier
+	reset    PasswordResetConfig
+
+	totpIssuer string
+
+	attempts  LoginAttemptStore
+	throttle  LoginThrottleConfig
+	hashSlots chan struct{}
+	hashWait  time.Duration
+	audit     AuditRecorder
+
+	registration RegistrationConfig
+
+	roleCache Cache
+	roleTTL   time.Duration
 }
 
-func NewAuthService(log *slog.Logger, repo AuthRepository) *AuthService {
-	return &AuthService{
-		Logger: log,
-		repo:   repo,
+type AuthOption func(srv *AuthService)
+
+// WithTokenCache удаляет отозванные токены из кэша, которым пользуется
+// проверка токенов в DocumentService. Можно передать несколько кэшей.
+func WithTokenCache(cache Cache) AuthOption {
+	return func(srv *AuthService) {
+		srv.caches = append(srv.caches, cache)
 	}
 }
 
-func (srv *AuthService) CreateUser(ctx context.Context, user *domain.User) (string, error) {
+func NewAuthService(log *slog.Logger, repo AuthRepository, ttl domain.TokenTTL, options ...AuthOption) *AuthService {
+	srv := &AuthService{
+		Logger:     log,
+		repo:       repo,
+		ttl:        ttl,
+		totpIssuer: defaultTOTPIssuer,
+	}
+	for _, option := range options {
+		option(srv)
+	}
+
+	return srv
+}
+
+// CreateUser регистрирует пользователя. В зависимости от режима регистрации
+// token — общий токен регистрации или код приглашения; в открытом режиме он
+// не проверяется.
+func (srv *AuthService) CreateUser(ctx context.Context, user *domain.User, token string) (string, error) {
 	const op = "service.AuthService.CreateUser"
 
 	log := srv.Logger.With("op", op)
 
-	hash, err := HashPassword(user.Password)
+	if err := srv.checkRegistrationToken(token); err != nil {
+		return "", err
+	}
+
+	hash, err := srv.hashPassword(ctx, user.Password)
 	if err != nil {
 		return "", err
 	}
@@ -41,7 +119,15 @@ func (srv *AuthService) CreateUser(ctx context.Context, user *domain.User) (stri
 	user.ID = userID.String()
 	user.Password = string(hash)
 
-	login, err := srv.repo.SaveUser(ctx, user)
+	var login string
+	if srv.registration.Mode == domain.RegistrationInvite {
+		login, err = srv.repo.SaveUserWithInvite(ctx, user, hashCode(token))
+	} else {
+		login, err = srv.repo.SaveUser(ctx, user)
+	}
+	if errors.Is(err, domain.ErrInvalidInvite) {
+		return "", err
+	}
 	if err != nil {
 		log.Error(
 			"Entity creation error",
@@ -53,29 +139,220 @@ func (srv *AuthService) CreateUser(ctx context.Context, user *domain.User) (stri
 	return login, nil
 }
 
-func (srv *AuthService) LoginUser(ctx context.Context, user *domain.User) (string, error) {
+// LoginUser проверяет логин, пароль и, если он включён, второй фактор и
+// открывает новую сессию: каждый вход получает собственную пару токенов.
+// Неудачные попытки замедляют и временно блокируют следующие.
+func (srv *AuthService) LoginUser(ctx context.Context, user *domain.User, otp string, info domain.SessionInfo) (*domain.TokenPair, error) {
 	const op = "service.AuthService.LoginUser"
 
-	_ = srv.Logger.With("op", op)
+	log := srv.Logger.With("op", op)
 
-	data, err := srv.repo.GetByID(ctx, user.ID)
+	reserved, err := srv.reserveLoginAttempt(ctx, srv.throttleKeys(user.Login, info.IP))
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+
+	creds, err := srv.repo.GetCredentialsByLogin(ctx, user.Login)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Сравнение с фиктивным хэшем выравнивает время ответа, чтобы по нему
+		// нельзя было перебирать существующие логины.
+		if err := srv.checkDummyPassword(ctx, user.Password); err != nil {
+			srv.releaseLoginAttempt(ctx, reserved)
+			return nil, err
+		}
+		srv.recordLoginFailure(ctx, reserved, "", info)
+		return nil, domain.ErrInvalidCredentials
+	}
+	if err != nil {
+		srv.releaseLoginAttempt(ctx, reserved)
+		return nil, err
+	}
+
+	ok, err := srv.checkPassword(ctx, user.Password, creds.Password)
+	if err != nil {
+		srv.releaseLoginAttempt(ctx, reserved)
+		return nil, err
+	}
+	if !ok {
+		srv.recordLoginFailure(ctx, reserved, creds.UserID, info)
+		return nil, domain.ErrInvalidCredentials
 	}
 
-	if !CheckPasswordHash(user.Password, data.Password) {
-		return "", nil
+	err = srv.verifySecondFactor(ctx, creds.UserID, otp)
+	if errors.Is(err, domain.ErrInvalidOTP) {
+		srv.recordLoginFailure(ctx, reserved, creds.UserID, info)
+		return nil, err
+	}
+	if err != nil {
+		srv.releaseLoginAttempt(ctx, reserved)
+		return nil, err
 	}
 
-	return data.Token, nil
+	srv.resetLoginFailures(ctx, reserved)
+
+	pair, err := srv.repo.IssueTokens(ctx, creds.UserID, srv.ttl, info)
+	if err != nil {
+		log.Error(
+			"Failed to issue tokens",
+			slog.String("err", err.Error()),
+		)
+		return nil, err
+	}
+
+	return pair, nil
 }
 
-func (srv *AuthService) RevokeToken(ctx context.Context, token string) error {
-	return srv.repo.RevokeToken(ctx, token)
+// ChangePassword проверяет текущий пароль, сохраняет новый и завершает все
+// сессии пользователя, кроме той, из которой пришёл запрос.
+func (srv *AuthService) ChangePassword(ctx context.Context, principal *domain.Principal, current, password string) error {
+	const op = "service.AuthService.ChangePassword"
+
+	log := srv.Logger.With("op", op)
+
+	creds, err := srv.repo.GetCredentialsByUserID(ctx, principal.UserID)
+	if err != nil {
+		return err
+	}
+
+	ok, err := srv.checkPassword(ctx, current, creds.Password)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return domain.ErrInvalidCredentials
+	}
+
+	hash, err := srv.hashPassword(ctx, password)
+	if err != nil {
+		return err
+	}
+
+	revoked, err := srv.repo.ChangePassword(ctx, principal.UserID, hash, principal.Token)
+	if err != nil {
+		log.Error(
+			"Failed to change password",
+			slog.String("err", err.Error()),
+		)
+		return err
+	}
+
+	srv.forgetTokens(ctx, revoked...)
+	return nil
+}
+
+// RefreshTokens обменивает refresh-токен на новую пару. При повторном
+// предъявлении токена всё семейство отзывается, а его access-токены
+// убираются из кэшей.
+func (srv *AuthService) RefreshTokens(ctx context.Context, refreshToken string, info domain.SessionInfo) (*domain.TokenPair, error) {
+	const op = "service.AuthService.RefreshTokens"
+
+	log := srv.Logger.With("op", op)
+
+	pair, stale, err := srv.repo.RotateRefreshToken(ctx, refreshToken, srv.ttl, info)
+	srv.forgetTokens(ctx, stale...)
+	if errors.Is(err, domain.ErrTokenReused) {
+		log.Warn(
+			"Refresh token reuse detected, token family revoked",
+			slog.Int("revoked", len(stale)),
+		)
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return pair, nil
+}
+
+func (srv *AuthService) ListSessions(ctx context.Context, userID, currentToken string) ([]domain.Session, error) {
+	return srv.repo.ListSessions(ctx, userID, currentToken)
+}
+
+func (srv *AuthService) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	revoked, err := srv.repo.RevokeSession(ctx, userID, sessionID)
+	if err != nil {
+		return err
+	}
+
+	srv.forgetTokens(ctx, revoked...)
+	return nil
+}
+
+// RevokeAllSessions завершает все сессии пользователя, включая текущую.
+func (srv *AuthService) RevokeAllSessions(ctx context.Context, userID string) error {
+	revoked, err := srv.repo.RevokeAllSessions(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	srv.forgetTokens(ctx, revoked...)
+	return nil
 }
 
+func (srv *AuthService) DeleteExpiredTokens(ctx context.Context, limit uint) (int, error) {
+	return srv.repo.DeleteExpiredTokens(ctx, limit)
+}
+
+// RevokedTokens возвращает отозванные, но ещё не истёкшие JWT.
+func (srv *AuthService) RevokedTokens(ctx context.Context) ([]domain.RevokedToken, error) {
+	const op = "service.AuthService.RevokedTokens"
+
+	log := srv.Logger.With("op", op)
+
+	tokens, err := srv.repo.ListRevokedTokens(ctx)
+	if err != nil {
+		log.Error(
+			"Failed to list revoked tokens",
+			slog.String("err", err.Error()),
+		)
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// RevokeToken завершает сессию, к которой относится токен; чужие токены
+// не найдутся.
+func (srv *AuthService) RevokeToken(ctx context.Context, userID, token string) error {
+	revoked, err := srv.repo.RevokeToken(ctx, userID, token)
+	if err != nil {
+		return err
+	}
+
+	srv.forgetTokens(ctx, revoked...)
+	return nil
+}
+
+// forgetTokens удаляет токены из кэшей проверки токенов.
+func (srv *AuthService) forgetTokens(ctx context.Context, tokens ...string) {
+	if len(tokens) == 0 {
+		return
+	}
+
+	keys := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		keys = append(keys, tokenCacheKey(token))
+	}
+
+	for _, cache := range srv.caches {
+		if err := cache.Delete(context.WithoutCancel(ctx), keys...); err != nil {
+			srv.Logger.Error(
+				"Failed to invalidate cached token",
+				slog.String("err", err.Error()),
+			)
+		}
+	}
+}
+
+// dummyPasswordHash — bcrypt-хэш той же стоимости, что и у HashPassword;
+// считается при первом неудачном входе, а не при старте. Вызывается только
+// из checkDummyPassword, под ограничением на число вычислений bcrypt.
+var dummyPasswordHash = sync.OnceValue(func() string {
+	hash, _ := HashPassword("dummy-password")
+	return hash
+})
+
 func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), passwordCost)
 	return string(bytes), err
 }
 
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// LocalCache — кэш в памяти процесса, который можно сбросить целиком.
+type LocalCache interface {
+	Cache
+	Purge()
+}
+
+type CacheTTL struct {
+	Document time.Duration
+	Access   time.Duration
+	Token    time.Duration
+}
+
+func documentCacheKey(id string) string {
+	return "doc:" + id
+}
+
+func accessCacheKey(documentID, userID string) string {
+	return "access:" + documentID + ":" + userID
+}
+
+// tokenCacheKey хранит хэш токена, чтобы сами токены не попадали в кэш.
+func tokenCacheKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return tokenHashCacheKey(hex.EncodeToString(sum[:]))
+}
+
+func tokenHashCacheKey(hash string) string {
+	return "token:" + hash
+}
+
+func loginAttemptsCacheKey(key string) string {
+	return "login-attempts:" + key
+}
+
+func roleCacheKey(userID string) string {
+	return "role:" + userID
+}
@@ -2,40 +2,240 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
 	"path/filepath"
+	"time"
 
 	"github.com/DENFNC/web-test/internal/domain"
 	"github.com/DENFNC/web-test/internal/infra/psql/repository"
 	"github.com/DENFNC/web-test/internal/transport/dto/request"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
+const (
+	defaultDocumentLimit = 100
+	maxDocumentLimit     = 1000
+)
+
+type Storage interface {
+	Save(name string, r io.Reader) (int64, error)
+	Open(name string) (io.ReadSeekCloser, error)
+	Remove(name string) error
+}
+
+type Scanner interface {
+	Scan(ctx context.Context, r io.Reader) (*domain.ScanResult, error)
+}
+
+type DocumentOption func(s *DocumentService)
+
+type LockConfig struct {
+	DefaultTTL time.Duration
+	MaxTTL     time.Duration
+}
+
 type DocumentService struct {
+	*slog.Logger
 	DocRepo  *repository.DocumentRepository
 	AuthRepo *repository.AuthRepository
+	Storage  Storage
+	Quota    domain.Quota
+	Locks    LockConfig
+
+	scanner        Scanner
+	scanFailClosed bool
+	audit          AuditRecorder
+	publishers     []EventPublisher
+	cache          Cache
+	cacheTTL       CacheTTL
+
+	tokenLRU         LocalCache
+	tokenTTL         time.Duration
+	tokenNegativeTTL time.Duration
+}
+
+type EventPublisher interface {
+	Publish(ctx context.Context, event *domain.DocumentEvent)
+}
+
+// WithEventPublisher добавляет получателя событий об изменении документов.
+func WithEventPublisher(publisher EventPublisher) DocumentOption {
+	return func(s *DocumentService) {
+		s.publishers = append(s.publishers, publisher)
+	}
+}
+
+type AuditRecorder interface {
+	Record(ctx context.Context, event *domain.AuditEvent)
+}
+
+// WithAuditLog включает запись системных событий, у которых нет инициатора
+// запроса, например удаление просроченных документов.
+func WithAuditLog(audit AuditRecorder) DocumentOption {
+	return func(s *DocumentService) {
+		s.audit = audit
+	}
+}
+
+// WithCache включает кэширование метаданных документов, проверок доступа и
+// токенов. Записи удаляются при изменении документа или выдаче доступа.
+func WithCache(cache Cache, ttl CacheTTL) DocumentOption {
+	return func(s *DocumentService) {
+		s.cache = cache
+		s.cacheTTL = ttl
+	}
+}
+
+// WithTokenLRU добавляет локальный кэш проверки токенов перед общим кэшем
+// и базой. Неизвестные и отозванные токены запоминаются на negativeTTL.
+func WithTokenLRU(lru LocalCache, ttl, negativeTTL time.Duration) DocumentOption {
+	return func(s *DocumentService) {
+		s.tokenLRU = lru
+		s.tokenTTL = ttl
+		s.tokenNegativeTTL = negativeTTL
+	}
+}
+
+// WithScanner включает проверку загрузок. При failClosed документ, который
+// не удалось проверить, помещается в карантин; иначе он остаётся доступным.
+func WithScanner(scanner Scanner, failClosed bool) DocumentOption {
+	return func(s *DocumentService) {
+		s.scanner = scanner
+		s.scanFailClosed = failClosed
+	}
 }
 
-func NewDocumentService(docRepo *repository.DocumentRepository, authRepo *repository.AuthRepository) *DocumentService {
-	return &DocumentService{
+func NewDocumentService(
+	log *slog.Logger,
+	docRepo *repository.DocumentRepository,
+	authRepo *repository.AuthRepository,
+	storage Storage,
+	quota domain.Quota,
+	locks LockConfig,
+	options ...DocumentOption,
+) *DocumentService {
+	srv := &DocumentService{
+		Logger:   log,
 		DocRepo:  docRepo,
 		AuthRepo: authRepo,
+		Storage:  storage,
+		Quota:    quota,
+		Locks:    locks,
 	}
+	for _, option := range options {
+		option(srv)
+	}
+
+	return srv
 }
 
 func (s *DocumentService) ValidateToken(ctx context.Context, token string) (string, error) {
-	return s.AuthRepo.GetUserIDByToken(ctx, token)
+	key := tokenCacheKey(token)
+
+	if s.tokenLRU != nil {
+		if data, ok, _ := s.tokenLRU.Get(ctx, key); ok {
+			if len(data) == 0 {
+				return "", domain.ErrInvalidToken
+			}
+			return string(data), nil
+		}
+	}
+
+	// В общий кэш попадают только владелец и срок действия, без самого
+	// токена.
+	var cached domain.AuthToken
+	if s.cacheGet(ctx, key, &cached) && cached.ExpiresAt.After(time.Now()) {
+		s.rememberToken(ctx, key, cached.UserID, cached.ExpiresAt)
+		return cached.UserID, nil
+	}
+
+	authToken, err := s.AuthRepo.GetActiveToken(ctx, token)
+	if errors.Is(err, pgx.ErrNoRows) {
+		s.rememberToken(ctx, key, "", time.Time{})
+		return "", domain.ErrInvalidToken
+	}
+	if err != nil {
+		return "", err
+	}
+
+	s.cacheSet(ctx, key, domain.AuthToken{
+		UserID:    authToken.UserID,
+		ExpiresAt: authToken.ExpiresAt,
+	}, min(s.cacheTTL.Token, time.Until(authToken.ExpiresAt)))
+	s.rememberToken(ctx, key, authToken.UserID, authToken.ExpiresAt)
+
+	return authToken.UserID, nil
+}
+
+// rememberToken кладёт результат проверки в локальный кэш не дольше срока
+// действия токена; пустой userID означает недействительный токен.
+func (s *DocumentService) rememberToken(ctx context.Context, key, userID string, expiresAt time.Time) {
+	if s.tokenLRU == nil {
+		return
+	}
+
+	ttl := s.tokenNegativeTTL
+	if userID != "" {
+		ttl = min(s.tokenTTL, time.Until(expiresAt))
+	}
+	if ttl > 0 {
+		_ = s.tokenLRU.Set(ctx, key, []byte(userID), ttl)
+	}
+}
+
+// EvictToken принимает уведомление об отзыве токена с его SHA-256.
+func (s *DocumentService) EvictToken(hash string) {
+	if s.tokenLRU != nil {
+		_ = s.tokenLRU.Delete(context.Background(), tokenHashCacheKey(hash))
+	}
+}
+
+// ResetTokens сбрасывает локальный кэш токенов, если уведомления об отзыве
+// могли быть потеряны.
+func (s *DocumentService) ResetTokens() {
+	if s.tokenLRU != nil {
+		s.tokenLRU.Purge()
+	}
 }
 
 func (s *DocumentService) FindUserIDByLogin(ctx context.Context, login string) (string, error) {
 	return s.AuthRepo.GetUserIDByLogin(ctx, login)
 }
 
-func (s *DocumentService) SaveDocument(ctx context.Context, meta request.DocumentMetaRequest, ownerID string, originalName string) (string, string, error) {
+func (s *DocumentService) SaveDocument(
+	ctx context.Context,
+	meta request.DocumentMetaRequest,
+	ownerID string,
+	originalName string,
+	size int64,
+	content io.Reader,
+) (*domain.Document, error) {
+	// Предварительная проверка, чтобы не писать на диск заведомо лишнее.
+	// Окончательно квота проверяется в транзакции вставки документа.
+	usage, err := s.DocRepo.GetUsage(ctx, ownerID, s.Quota)
+	if err != nil {
+		return nil, err
+	}
+	if !usage.Allows(size, 1) {
+		return nil, domain.ErrQuotaExceeded
+	}
+
 	id := uuid.New()
 	ext := filepath.Ext(originalName)
 	uuidFileName := uuid.New().String() + ext
 
+	hash := sha256.New()
+	written, err := s.Storage.Save(uuidFileName, io.TeeReader(content, hash))
+	if err != nil {
+		return nil, err
+	}
+
 	doc := &domain.Document{
 		ID:       id.String(),
 		FileName: uuidFileName,
@@ -43,19 +243,332 @@ func (s *DocumentService) SaveDocument(ctx context.Context, meta request.Documen
 		HasFile:  true,
 		IsPublic: meta.Public,
 		OwnerID:  ownerID,
+		Size:     written,
+		Checksum: hex.EncodeToString(hash.Sum(nil)),
+	}
+	if meta.ExpiresAt != nil {
+		doc.ExpiresAt = *meta.ExpiresAt
+	}
+	s.scanDocument(ctx, doc)
+
+	newID, err := s.DocRepo.SaveDocument(ctx, doc, s.Quota)
+	if err != nil {
+		_ = s.Storage.Remove(uuidFileName)
+		return nil, err
+	}
+	doc.ID = newID
+
+	s.publish(ctx, domain.EventDocumentCreated, doc, "")
+
+	return doc, nil
+}
+
+// UpdateDocumentContent заменяет файл документа новым содержимым. Старый
+// файл удаляется только после успешного обновления записи.
+func (s *DocumentService) UpdateDocumentContent(
+	ctx context.Context,
+	doc *domain.Document,
+	actorID string,
+	ifVersion int64,
+	mimeType string,
+	size int64,
+	content io.Reader,
+) (*domain.Document, error) {
+	const op = "service.DocumentService.UpdateDocumentContent"
+
+	log := s.Logger.With("op", op)
+
+	// Заявленный размер может быть неизвестен (chunked) или занижен, поэтому
+	// кроме предварительной проверки тело читается не дальше остатка квоты:
+	// иначе файл целиком попал бы на диск до проверки в транзакции.
+	usage, err := s.DocRepo.GetUsage(ctx, doc.OwnerID, s.Quota)
+	if err != nil {
+		return nil, err
+	}
+	if size > doc.Size && !usage.Allows(size-doc.Size, 0) {
+		return nil, domain.ErrQuotaExceeded
+	}
+	if usage.QuotaBytes > 0 {
+		content = &quotaReader{r: content, left: usage.QuotaBytes - usage.BytesUsed + doc.Size}
+	}
+
+	updated := *doc
+	updated.FileName = uuid.New().String() + filepath.Ext(doc.FileName)
+	if mimeType != "" {
+		updated.MimeType = mimeType
+	}
+
+	hash := sha256.New()
+	written, err := s.Storage.Save(updated.FileName, io.TeeReader(content, hash))
+	if err != nil {
+		return nil, err
+	}
+	updated.Size = written
+	updated.Checksum = hex.EncodeToString(hash.Sum(nil))
+	updated.ScanSignature = ""
+	updated.Quarantined = false
+	s.scanDocument(ctx, &updated)
+
+	old, err := s.DocRepo.UpdateDocumentContent(ctx, &updated, actorID, ifVersion, s.Quota)
+	if err != nil {
+		_ = s.Storage.Remove(updated.FileName)
+		return nil, err
+	}
+	s.invalidate(ctx, documentCacheKey(doc.ID))
+
+	if old.HasFile && !old.SharedBlob {
+		if err := s.Storage.Remove(old.FileName); err != nil {
+			log.Error(
+				"Failed to remove replaced document file",
+				slog.String("id", doc.ID),
+				slog.String("err", err.Error()),
+			)
+		}
+	}
+
+	s.publish(ctx, domain.EventDocumentUpdated, &updated, "")
+
+	return &updated, nil
+}
+
+func (s *DocumentService) UpdateDocumentMeta(ctx context.Context, id, actorID string, ifVersion int64, patch domain.DocumentPatch) (*domain.Document, error) {
+	doc, err := s.DocRepo.UpdateDocumentMeta(ctx, id, actorID, ifVersion, patch)
+	if err != nil {
+		return nil, err
+	}
+	s.invalidate(ctx, documentCacheKey(id))
+
+	s.publish(ctx, domain.EventDocumentUpdated, doc, "")
+
+	return doc, nil
+}
+
+// LockDocument захватывает документ на ttl, ограниченный LockConfig.MaxTTL.
+func (s *DocumentService) LockDocument(ctx context.Context, documentID, userID string, ttl time.Duration) (*domain.DocumentLock, error) {
+	switch {
+	case ttl <= 0:
+		ttl = s.Locks.DefaultTTL
+	case ttl > s.Locks.MaxTTL:
+		ttl = s.Locks.MaxTTL
+	}
+
+	return s.DocRepo.AcquireLock(ctx, &domain.DocumentLock{
+		DocumentID: documentID,
+		UserID:     userID,
+		ExpiresAt:  time.Now().Add(ttl),
+	})
+}
+
+func (s *DocumentService) GetDocumentLock(ctx context.Context, documentID string) (*domain.DocumentLock, error) {
+	return s.DocRepo.GetLock(ctx, documentID)
+}
+
+func (s *DocumentService) UnlockDocument(ctx context.Context, documentID, userID string, force bool) error {
+	return s.DocRepo.ReleaseLock(ctx, documentID, userID, force)
+}
+
+// CopyDocument создаёт копию документа в пространстве ownerID. Копия не
+// публичная, без срока хранения и выданных доступов, а файл у неё общий с
+// исходным документом.
+func (s *DocumentService) CopyDocument(ctx context.Context, srcID, ownerID string) (*domain.Document, error) {
+	doc, err := s.DocRepo.CopyDocument(ctx, srcID, uuid.New().String(), ownerID, s.Quota)
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(ctx, domain.EventDocumentCreated, doc, "")
+
+	return doc, nil
+}
+
+func (s *DocumentService) OfferTransfer(ctx context.Context, transfer *domain.DocumentTransfer) (*domain.DocumentTransfer, error) {
+	return s.DocRepo.OfferTransfer(ctx, transfer)
+}
+
+func (s *DocumentService) GetTransfer(ctx context.Context, documentID string) (*domain.DocumentTransfer, error) {
+	return s.DocRepo.GetTransfer(ctx, documentID)
+}
+
+func (s *DocumentService) ListIncomingTransfers(ctx context.Context, userID string) ([]*domain.DocumentTransfer, error) {
+	return s.DocRepo.ListIncomingTransfers(ctx, userID)
+}
+
+func (s *DocumentService) CancelTransfer(ctx context.Context, documentID, userID string) error {
+	return s.DocRepo.CancelTransfer(ctx, documentID, userID)
+}
+
+func (s *DocumentService) AcceptTransfer(ctx context.Context, documentID, userID string) (*domain.Document, error) {
+	doc, from, err := s.DocRepo.AcceptTransfer(ctx, documentID, userID, s.Quota)
+	if err != nil {
+		return nil, err
+	}
+	s.invalidateTransfer(ctx, doc, from)
+
+	s.publish(ctx, domain.EventDocumentTransferred, doc, from)
+
+	return doc, nil
+}
+
+// ForceTransfer передаёт документ без согласия получателя; вызывается
+// администратором.
+func (s *DocumentService) ForceTransfer(ctx context.Context, documentID, toUserID string, keepAccess bool) (*domain.Document, error) {
+	doc, from, err := s.DocRepo.ForceTransfer(ctx, documentID, toUserID, keepAccess, s.Quota)
+	if err != nil {
+		return nil, err
+	}
+	s.invalidateTransfer(ctx, doc, from)
+
+	s.publish(ctx, domain.EventDocumentTransferred, doc, from)
+
+	return doc, nil
+}
+
+func (s *DocumentService) invalidateTransfer(ctx context.Context, doc *domain.Document, from string) {
+	s.invalidate(ctx,
+		documentCacheKey(doc.ID),
+		accessCacheKey(doc.ID, from),
+		accessCacheKey(doc.ID, doc.OwnerID),
+	)
+}
+
+// cacheGet читает значение из кэша. Ошибки кэша не прерывают запрос: он
+// выполняется так, как если бы кэша не было.
+func (s *DocumentService) cacheGet(ctx context.Context, key string, dst any) bool {
+	const op = "service.DocumentService.cacheGet"
+
+	if s.cache == nil {
+		return false
+	}
+
+	data, ok, err := s.cache.Get(ctx, key)
+	if err != nil {
+		s.Logger.With("op", op).Warn(
+			"Failed to read cache",
+			slog.String("key", key),
+			slog.String("err", err.Error()),
+		)
+		return false
+	}
+	if !ok {
+		return false
+	}
+	return json.Unmarshal(data, dst) == nil
+}
+
+func (s *DocumentService) cacheSet(ctx context.Context, key string, value any, ttl time.Duration) {
+	const op = "service.DocumentService.cacheSet"
+
+	if s.cache == nil || ttl <= 0 {
+		return
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+	if err := s.cache.Set(ctx, key, data, ttl); err != nil {
+		s.Logger.With("op", op).Warn(
+			"Failed to write cache",
+			slog.String("key", key),
+			slog.String("err", err.Error()),
+		)
+	}
+}
+
+func (s *DocumentService) invalidate(ctx context.Context, keys ...string) {
+	const op = "service.DocumentService.invalidate"
+
+	if s.cache == nil {
+		return
+	}
+
+	if err := s.cache.Delete(context.WithoutCancel(ctx), keys...); err != nil {
+		s.Logger.With("op", op).Error(
+			"Failed to invalidate cache",
+			slog.Any("keys", keys),
+			slog.String("err", err.Error()),
+		)
+	}
+}
+
+func (s *DocumentService) publish(ctx context.Context, eventType string, doc *domain.Document, userID string) {
+	if len(s.publishers) == 0 {
+		return
+	}
+
+	audience := doc.SharedWith
+	if audience == nil {
+		audience, _ = s.DocRepo.ListDocumentAccess(ctx, doc.ID)
+	}
+
+	event := &domain.DocumentEvent{
+		Type:       eventType,
+		DocumentID: doc.ID,
+		OwnerID:    doc.OwnerID,
+		UserID:     userID,
+		OccurredAt: time.Now().UTC(),
+		Audience:   audience,
+	}
+	for _, publisher := range s.publishers {
+		publisher.Publish(ctx, event)
+	}
+}
+
+func (s *DocumentService) scanDocument(ctx context.Context, doc *domain.Document) {
+	const op = "service.DocumentService.scanDocument"
+
+	log := s.Logger.With("op", op)
+
+	doc.ScanStatus = domain.ScanStatusUnscanned
+	if s.scanner == nil {
+		return
+	}
+
+	result, err := s.scanBlob(ctx, doc.FileName)
+	if err != nil {
+		log.Error(
+			"Document scan failed",
+			slog.String("file", doc.FileName),
+			slog.Bool("fail_closed", s.scanFailClosed),
+			slog.String("err", err.Error()),
+		)
+		doc.ScanStatus = domain.ScanStatusFailed
+		doc.Quarantined = s.scanFailClosed
+		return
+	}
+
+	if result.Infected {
+		log.Warn(
+			"Malware detected, document quarantined",
+			slog.String("file", doc.FileName),
+			slog.String("signature", result.Signature),
+		)
+		doc.ScanStatus = domain.ScanStatusInfected
+		doc.ScanSignature = result.Signature
+		doc.Quarantined = true
+		return
 	}
-	newID, err := s.DocRepo.SaveDocument(ctx, doc)
+
+	doc.ScanStatus = domain.ScanStatusClean
+}
+
+func (s *DocumentService) scanBlob(ctx context.Context, name string) (*domain.ScanResult, error) {
+	file, err := s.Storage.Open(name)
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
-	return newID, uuidFileName, nil
+	defer file.Close()
+
+	return s.scanner.Scan(ctx, file)
 }
 
-func (s *DocumentService) AddDocumentAccess(ctx context.Context, documentID string, userIDs []string) error {
+func (s *DocumentService) AddDocumentAccess(ctx context.Context, doc *domain.Document, userIDs []string) error {
 	for _, userID := range userIDs {
-		if err := s.DocRepo.AddDocumentAccess(ctx, documentID, userID); err != nil {
+		if err := s.DocRepo.AddDocumentAccess(ctx, doc.ID, userID); err != nil {
 			return err
 		}
+		s.invalidate(ctx, accessCacheKey(doc.ID, userID))
+		s.publish(ctx, domain.EventAccessGranted, doc, userID)
 	}
 	return nil
 }
@@ -72,13 +585,131 @@ func (s *DocumentService) ParseOptionalJSON(jsonStr string) (map[string]interfac
 }
 
 func (s *DocumentService) GetDocumentByID(ctx context.Context, id string) (*domain.Document, error) {
-	return s.DocRepo.GetDocumentByID(ctx, id)
+	key := documentCacheKey(id)
+
+	var cached domain.Document
+	if s.cacheGet(ctx, key, &cached) {
+		if !cached.ExpiresAt.IsZero() && !cached.ExpiresAt.After(time.Now()) {
+			return nil, pgx.ErrNoRows
+		}
+		return &cached, nil
+	}
+
+	doc, err := s.DocRepo.GetDocumentByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.cacheSet(ctx, key, doc, s.cacheTTL.Document)
+
+	return doc, nil
+}
+
+func (s *DocumentService) ListDocuments(ctx context.Context, filter domain.DocumentFilter) ([]*domain.Document, error) {
+	switch {
+	case filter.Limit == 0:
+		filter.Limit = defaultDocumentLimit
+	case filter.Limit > maxDocumentLimit:
+		filter.Limit = maxDocumentLimit
+	}
+
+	return s.DocRepo.ListDocuments(ctx, filter)
+}
+
+func (s *DocumentService) OpenDocumentFile(doc *domain.Document) (io.ReadSeekCloser, error) {
+	return s.Storage.Open(doc.FileName)
 }
 
 func (s *DocumentService) HasDocumentAccess(ctx context.Context, documentID, userID string) (bool, error) {
-	return s.DocRepo.HasDocumentAccess(ctx, documentID, userID)
+	key := accessCacheKey(documentID, userID)
+
+	var ok bool
+	if s.cacheGet(ctx, key, &ok) {
+		return ok, nil
+	}
+
+	ok, err := s.DocRepo.HasDocumentAccess(ctx, documentID, userID)
+	if err != nil {
+		return false, err
+	}
+
+	s.cacheSet(ctx, key, ok, s.cacheTTL.Access)
+
+	return ok, nil
 }
 
-func (s *DocumentService) DeleteDocument(ctx context.Context, id string) error {
-	return s.DocRepo.DeleteDocument(ctx, id)
+func (s *DocumentService) DeleteDocument(ctx context.Context, id string, ifVersion int64) error {
+	doc, err := s.DocRepo.DeleteDocument(ctx, id, ifVersion)
+	if err != nil {
+		return err
+	}
+	s.invalidate(ctx, documentCacheKey(id))
+
+	s.publish(ctx, domain.EventDocumentDeleted, doc, "")
+
+	if doc.HasFile && !doc.SharedBlob {
+		return s.Storage.Remove(doc.FileName)
+	}
+	return nil
+}
+
+func (s *DocumentService) DeleteExpiredDocuments(ctx context.Context, limit uint) (int, error) {
+	const op = "service.DocumentService.DeleteExpiredDocuments"
+
+	log := s.Logger.With("op", op)
+
+	docs, err := s.DocRepo.DeleteExpiredDocuments(ctx, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, doc := range docs {
+		s.invalidate(ctx, documentCacheKey(doc.ID))
+		if s.audit != nil {
+			s.audit.Record(ctx, &domain.AuditEvent{
+				DocumentID: doc.ID,
+				Action:     domain.AuditDocumentExpire,
+			})
+		}
+		s.publish(ctx, domain.EventDocumentDeleted, doc, "")
+
+		if !doc.HasFile || doc.SharedBlob {
+			continue
+		}
+		if err := s.Storage.Remove(doc.FileName); err != nil {
+			log.Error(
+				"Failed to remove expired document file",
+				slog.String("id", doc.ID),
+				slog.String("err", err.Error()),
+			)
+		}
+	}
+
+	return len(docs), nil
+}
+
+func (s *DocumentService) GetUsage(ctx context.Context, userID string) (*domain.Usage, error) {
+	return s.DocRepo.GetUsage(ctx, userID, s.Quota)
+}
+
+// quotaReader возвращает ErrQuotaExceeded, как только прочитано больше left
+// байт.
+type quotaReader struct {
+	r    io.Reader
+	left int64
+}
+
+func (q *quotaReader) Read(p []byte) (int, error) {
+	if q.left < 0 {
+		return 0, domain.ErrQuotaExceeded
+	}
+	if int64(len(p)) > q.left+1 {
+		p = p[:q.left+1]
+	}
+	n, err := q.r.Read(p)
+	q.left -= int64(n)
+	if q.left < 0 {
+		return n, domain.ErrQuotaExceeded
+	}
+	return n, err
 }
@@ -0,0 +1,382 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/DENFNC/web-test/internal/domain"
+)
+
+// LoginAttemptStore хранит счётчики неудачных входов. UpdateLoginAttempts
+// должна применять fn к актуальному значению; для отсутствующей или истёкшей
+// записи fn получает нулевое.
+type LoginAttemptStore interface {
+	GetLoginAttempts(ctx context.Context, key string) (*domain.LoginAttempts, error)
+	UpdateLoginAttempts(ctx context.Context, key string, fn func(attempts *domain.LoginAttempts)) (*domain.LoginAttempts, error)
+	ResetLoginAttempts(ctx context.Context, keys ...string) error
+}
+
+// LoginThrottleConfig задаёт защиту от подбора пароля. Неудачные входы
+// считаются отдельно по логину и по адресу клиента; нулевой лимит отключает
+// соответствующий счётчик.
+type LoginThrottleConfig struct {
+	MaxLoginFailures int32
+	MaxIPFailures    int32
+	// FailureWindow — через сколько после последней неудачи счётчик
+	// обнуляется.
+	FailureWindow   time.Duration
+	LockoutDuration time.Duration
+	// После n-й неудачи следующая попытка принимается не раньше чем через
+	// BaseDelay·2^(n-1), но не больше MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// WithLoginThrottle включает задержки и временную блокировку входа после
+// неудачных попыток.
+func WithLoginThrottle(store LoginAttemptStore, cfg LoginThrottleConfig) AuthOption {
+	return func(srv *AuthService) {
+		srv.attempts = store
+		srv.throttle = cfg
+	}
+}
+
+// WithHashConcurrency ограничивает число одновременных вычислений bcrypt;
+// запрос, не дождавшийся очереди за wait, получает ErrServerBusy. limit <= 0
+// означает число процессоров.
+func WithHashConcurrency(limit int, wait time.Duration) AuthOption {
+	return func(srv *AuthService) {
+		if limit <= 0 {
+			limit = runtime.NumCPU()
+		}
+		srv.hashSlots = make(chan struct{}, limit)
+		srv.hashWait = wait
+	}
+}
+
+// WithAuthAuditLog записывает в журнал аудита блокировки входа.
+func WithAuthAuditLog(audit AuditRecorder) AuthOption {
+	return func(srv *AuthService) {
+		srv.audit = audit
+	}
+}
+
+// throttleKey — счётчик неудач. Паузы между попытками действуют только для
+// логина: по адресу за NAT входит много пользователей, и их опечатки не
+// должны замедлять друг друга; адрес лишь блокируется по лимиту.
+type throttleKey struct {
+	key     string
+	limit   int32
+	delayed bool
+}
+
+func (srv *AuthService) throttleKeys(login, ip string) []throttleKey {
+	if srv.attempts == nil {
+		return nil
+	}
+
+	var keys []throttleKey
+	if srv.throttle.MaxLoginFailures > 0 {
+		keys = append(keys, throttleKey{
+			key:     "login:" + strings.ToLower(login),
+			limit:   srv.throttle.MaxLoginFailures,
+			delayed: true,
+		})
+	}
+	if srv.throttle.MaxIPFailures > 0 && ip != "" {
+		keys = append(keys, throttleKey{
+			key:   "ip:" + ip,
+			limit: srv.throttle.MaxIPFailures,
+		})
+	}
+	return keys
+}
+
+// loginReservation — попытка входа, заранее засчитанная как неудачная по
+// одному ключу. lockedUntil заполнен, если эта попытка исчерпала лимит.
+type loginReservation struct {
+	key         throttleKey
+	lockedUntil time.Time
+}
+
+// reserveLoginAttempt до проверки пароля атомарно засчитывает попытку как
+// неудачную по всем ключам. Блокировка и пауза проверяются по тому же
+// значению счётчика, которое увеличивается, поэтому одновременные попытки не
+// проходят проверку по одному и тому же состоянию. Если по одному из ключей
+// вход заблокирован или не выдержана пауза после предыдущей неудачи логина,
+// уже сделанные резервирования отменяются и возвращается ThrottleError.
+func (srv *AuthService) reserveLoginAttempt(ctx context.Context, keys []throttleKey) ([]loginReservation, error) {
+	throttled := &domain.ThrottleError{}
+	reserved := make([]loginReservation, 0, len(keys))
+	for _, key := range keys {
+		var (
+			wait        time.Duration
+			locked      bool
+			lockedUntil time.Time
+		)
+		_, err := srv.attempts.UpdateLoginAttempts(ctx, key.key, func(attempts *domain.LoginAttempts) {
+			now := time.Now()
+
+			if wait = attempts.LockedUntil.Sub(now); wait > 0 {
+				locked = true
+				return
+			}
+			if key.delayed && attempts.Failures > 0 {
+				next := attempts.LastFailureAt.Add(srv.loginDelay(attempts.Failures))
+				if wait = next.Sub(now); wait > 0 {
+					return
+				}
+			}
+
+			attempts.Failures++
+			attempts.LastFailureAt = now
+			attempts.ExpiresAt = now.Add(srv.throttle.FailureWindow)
+
+			if attempts.Failures >= key.limit {
+				attempts.Failures = 0
+				attempts.LockedUntil = now.Add(srv.throttle.LockoutDuration)
+				if attempts.LockedUntil.After(attempts.ExpiresAt) {
+					attempts.ExpiresAt = attempts.LockedUntil
+				}
+				lockedUntil = attempts.LockedUntil
+			}
+		})
+		if err != nil {
+			srv.releaseLoginAttempt(ctx, reserved)
+			return nil, err
+		}
+
+		if wait > 0 {
+			throttled.Locked = throttled.Locked || locked
+			throttled.RetryAfter = max(throttled.RetryAfter, wait)
+			continue
+		}
+		reserved = append(reserved, loginReservation{key: key, lockedUntil: lockedUntil})
+	}
+
+	if throttled.RetryAfter > 0 {
+		srv.releaseLoginAttempt(ctx, reserved)
+		return nil, throttled
+	}
+	return reserved, nil
+}
+
+// releaseLoginAttempt снимает резервирование попытки, которую не удалось
+// проверить, например из-за ошибки базы или очереди bcrypt. Снимается только
+// своя неудача и своя блокировка: сброс всего счётчика позволял бы обходить
+// ограничение, вызывая такие ошибки. Ошибки хранилища только логируются.
+func (srv *AuthService) releaseLoginAttempt(ctx context.Context, reserved []loginReservation) {
+	const op = "service.AuthService.releaseLoginAttempt"
+
+	log := srv.Logger.With("op", op)
+
+	ctx = context.WithoutCancel(ctx)
+	for _, r := range reserved {
+		_, err := srv.attempts.UpdateLoginAttempts(ctx, r.key.key, func(attempts *domain.LoginAttempts) {
+			switch {
+			case !r.lockedUntil.IsZero() && attempts.LockedUntil.Equal(r.lockedUntil):
+				attempts.LockedUntil = time.Time{}
+				attempts.Failures = r.key.limit - 1
+			case attempts.Failures > 0:
+				attempts.Failures--
+			}
+		})
+		if err != nil {
+			log.Error(
+				"Failed to release login attempt",
+				slog.String("key", r.key.key),
+				slog.String("err", err.Error()),
+			)
+		}
+	}
+}
+
+// resetLoginFailures после успешного входа обнуляет счётчик логина. Со
+// счётчика адреса снимается только резервирование этой попытки: иначе
+// удачный вход в свой аккаунт позволял бы продолжать подбор чужих паролей с
+// того же адреса.
+func (srv *AuthService) resetLoginFailures(ctx context.Context, reserved []loginReservation) {
+	var (
+		reset []string
+		rest  []loginReservation
+	)
+	for _, r := range reserved {
+		if r.key.delayed {
+			reset = append(reset, r.key.key)
+		} else {
+			rest = append(rest, r)
+		}
+	}
+
+	srv.releaseLoginAttempt(ctx, rest)
+	if len(reset) == 0 {
+		return
+	}
+
+	if err := srv.attempts.ResetLoginAttempts(context.WithoutCancel(ctx), reset...); err != nil {
+		srv.Logger.Error(
+			"Failed to reset login failures",
+			slog.String("err", err.Error()),
+		)
+	}
+}
+
+// recordLoginFailure подтверждает зарезервированную неудачу: счётчики уже
+// увеличены, остаётся сообщить о блокировках, которые вызвала эта попытка.
+func (srv *AuthService) recordLoginFailure(ctx context.Context, reserved []loginReservation, userID string, info domain.SessionInfo) {
+	const op = "service.AuthService.recordLoginFailure"
+
+	log := srv.Logger.With("op", op)
+
+	ctx = context.WithoutCancel(ctx)
+	for _, r := range reserved {
+		if r.lockedUntil.IsZero() {
+			continue
+		}
+
+		log.Warn(
+			"Login temporarily locked after repeated failures",
+			slog.String("key", r.key.key),
+			slog.Time("locked_until", r.lockedUntil),
+		)
+
+		if srv.audit != nil {
+			event := &domain.AuditEvent{
+				Action:    domain.AuditLoginLockout,
+				IP:        info.IP,
+				UserAgent: info.UserAgent,
+			}
+			// Блокировка по адресу не относится к конкретному пользователю.
+			if r.key.delayed {
+				event.ActorID = userID
+			}
+			srv.audit.Record(ctx, event)
+		}
+	}
+}
+
+func (srv *AuthService) loginDelay(failures int32) time.Duration {
+	if failures <= 0 || srv.throttle.BaseDelay <= 0 {
+		return 0
+	}
+
+	delay := srv.throttle.BaseDelay
+	for i := int32(1); i < failures && delay < srv.throttle.MaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, srv.throttle.MaxDelay)
+}
+
+// acquireHashSlot занимает место в очереди на вычисление bcrypt; вызывающий
+// обязан вызвать возвращённую функцию.
+func (srv *AuthService) acquireHashSlot(ctx context.Context) (func(), error) {
+	if srv.hashSlots == nil {
+		return func() {}, nil
+	}
+
+	timer := time.NewTimer(srv.hashWait)
+	defer timer.Stop()
+
+	select {
+	case srv.hashSlots <- struct{}{}:
+		return func() { <-srv.hashSlots }, nil
+	case <-timer.C:
+		return nil, domain.ErrServerBusy
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (srv *AuthService) hashPassword(ctx context.Context, password string) (string, error) {
+	release, err := srv.acquireHashSlot(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
+	return HashPassword(password)
+}
+
+func (srv *AuthService) checkPassword(ctx context.Context, password, hash string) (bool, error) {
+	release, err := srv.acquireHashSlot(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer release()
+
+	return CheckPasswordHash(password, hash), nil
+}
+
+// checkDummyPassword сравнивает пароль с фиктивным хэшем для неизвестного
+// логина. Первое вычисление хэша тоже занимает место в очереди на bcrypt.
+func (srv *AuthService) checkDummyPassword(ctx context.Context, password string) error {
+	release, err := srv.acquireHashSlot(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	CheckPasswordHash(password, dummyPasswordHash())
+	return nil
+}
+
+// CacheLoginAttemptStore хранит счётчики неудачных входов в кэше. Чтение и
+// запись не атомарны, поэтому при одновременных попытках часть неудач может
+// не засчитаться; с кэшем в памяти процесса счётчики не общие для
+// нескольких экземпляров приложения.
+type CacheLoginAttemptStore struct {
+	cache Cache
+}
+
+func NewCacheLoginAttemptStore(cache Cache) *CacheLoginAttemptStore {
+	return &CacheLoginAttemptStore{cache: cache}
+}
+
+func (s *CacheLoginAttemptStore) GetLoginAttempts(ctx context.Context, key string) (*domain.LoginAttempts, error) {
+	var attempts domain.LoginAttempts
+
+	data, ok, err := s.cache.Get(ctx, loginAttemptsCacheKey(key))
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return &attempts, nil
+	}
+
+	if err := json.Unmarshal(data, &attempts); err != nil || !attempts.ExpiresAt.After(time.Now()) {
+		return &domain.LoginAttempts{}, nil
+	}
+	return &attempts, nil
+}
+
+func (s *CacheLoginAttemptStore) UpdateLoginAttempts(ctx context.Context, key string, fn func(attempts *domain.LoginAttempts)) (*domain.LoginAttempts, error) {
+	attempts, err := s.GetLoginAttempts(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	fn(attempts)
+
+	ttl := time.Until(attempts.ExpiresAt)
+	if ttl <= 0 {
+		return attempts, s.cache.Delete(ctx, loginAttemptsCacheKey(key))
+	}
+
+	data, err := json.Marshal(attempts)
+	if err != nil {
+		return nil, err
+	}
+	return attempts, s.cache.Set(ctx, loginAttemptsCacheKey(key), data, ttl)
+}
+
+func (s *CacheLoginAttemptStore) ResetLoginAttempts(ctx context.Context, keys ...string) error {
+	cacheKeys := make([]string, 0, len(keys))
+	for _, key := range keys {
+		cacheKeys = append(cacheKeys, loginAttemptsCacheKey(key))
+	}
+	return s.cache.Delete(ctx, cacheKeys...)
+}
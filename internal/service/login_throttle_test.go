@@ -0,0 +1,31 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DENFNC/web-test/internal/domain"
+)
+
+func TestCheckDummyPassword_UsesHashLimiter(t *testing.T) {
+	srv := &AuthService{
+		hashSlots: make(chan struct{}, 1),
+		hashWait:  10 * time.Millisecond,
+	}
+
+	release, err := srv.acquireHashSlot(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := srv.checkDummyPassword(context.Background(), "password"); !errors.Is(err, domain.ErrServerBusy) {
+		t.Fatalf("checkDummyPassword() with busy limiter = %v, want ErrServerBusy", err)
+	}
+
+	release()
+	if err := srv.checkDummyPassword(context.Background(), "password"); err != nil {
+		t.Fatalf("checkDummyPassword() = %v", err)
+	}
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/DENFNC/web-test/internal/domain"
+)
+
+const (
+	subscriptionBuffer = 64
+	maxMissedEvents    = 1000
+)
+
+type EventRepository interface {
+	SaveUserEvents(ctx context.Context, event *domain.DocumentEvent, recipients []string) error
+	ListUserEvents(ctx context.Context, recipientID string, afterID int64, limit uint) ([]*domain.UserEvent, error)
+	DeleteUserEventsBefore(ctx context.Context, before time.Time, limit uint) (int, error)
+}
+
+// Subscription получает события одного пользователя. Канал Events
+// закрывается, если подписчик не успевает их читать или слушатель
+// переподключился: клиент должен переподключиться с Last-Event-ID.
+type Subscription struct {
+	Events chan *domain.UserEvent
+	userID string
+}
+
+// NotificationService сохраняет события документов для каждого получателя
+// и раздаёт их подписчикам этого экземпляра. Сами события приходят из
+// Postgres NOTIFY, поэтому подписчики видят изменения, сделанные через
+// любой экземпляр приложения.
+type NotificationService struct {
+	*slog.Logger
+	repo      EventRepository
+	retention time.Duration
+
+	mu          sync.Mutex
+	subscribers map[string]map[*Subscription]struct{}
+}
+
+func NewNotificationService(log *slog.Logger, repo EventRepository, retention time.Duration) *NotificationService {
+	return &NotificationService{
+		Logger:      log,
+		repo:        repo,
+		retention:   retention,
+		subscribers: make(map[string]map[*Subscription]struct{}),
+	}
+}
+
+func (srv *NotificationService) Publish(ctx context.Context, event *domain.DocumentEvent) {
+	const op = "service.NotificationService.Publish"
+
+	log := srv.Logger.With("op", op)
+
+	recipients := uniqueIDs(append(event.Recipients(), event.Audience...))
+	if err := srv.repo.SaveUserEvents(context.WithoutCancel(ctx), event, recipients); err != nil {
+		log.Error(
+			"Failed to save user events",
+			slog.String("event", event.Type),
+			slog.String("document_id", event.DocumentID),
+			slog.String("err", err.Error()),
+		)
+	}
+}
+
+func (srv *NotificationService) Subscribe(userID string) *Subscription {
+	sub := &Subscription{
+		Events: make(chan *domain.UserEvent, subscriptionBuffer),
+		userID: userID,
+	}
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	if srv.subscribers[userID] == nil {
+		srv.subscribers[userID] = make(map[*Subscription]struct{})
+	}
+	srv.subscribers[userID][sub] = struct{}{}
+
+	return sub
+}
+
+func (srv *NotificationService) Unsubscribe(sub *Subscription) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	srv.remove(sub)
+}
+
+// EventsSince возвращает события пользователя после afterID, чтобы клиент
+// мог продолжить поток после переподключения.
+func (srv *NotificationService) EventsSince(ctx context.Context, userID string, afterID int64) ([]*domain.UserEvent, error) {
+	return srv.repo.ListUserEvents(ctx, userID, afterID, maxMissedEvents)
+}
+
+// Dispatch принимает полезную нагрузку NOTIFY.
+func (srv *NotificationService) Dispatch(payload string) {
+	const op = "service.NotificationService.Dispatch"
+
+	log := srv.Logger.With("op", op)
+
+	var event domain.UserEvent
+	if err := json.Unmarshal([]byte(payload), &event); err != nil {
+		log.Error(
+			"Invalid notification payload",
+			slog.String("err", err.Error()),
+		)
+		return
+	}
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	for sub := range srv.subscribers[event.RecipientID] {
+		select {
+		case sub.Events <- &event:
+		default:
+			srv.remove(sub)
+		}
+	}
+}
+
+// Reset отключает всех подписчиков: после обрыва LISTEN часть событий могла
+// потеряться, и клиенты дочитают их при переподключении.
+func (srv *NotificationService) Reset() {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	for _, subs := range srv.subscribers {
+		for sub := range subs {
+			srv.remove(sub)
+		}
+	}
+}
+
+func (srv *NotificationService) DeleteOldEvents(ctx context.Context, limit uint) (int, error) {
+	return srv.repo.DeleteUserEventsBefore(ctx, time.Now().Add(-srv.retention), limit)
+}
+
+func (srv *NotificationService) remove(sub *Subscription) {
+	subs, ok := srv.subscribers[sub.userID]
+	if !ok {
+		return
+	}
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+
+	delete(subs, sub)
+	close(sub.Events)
+	if len(subs) == 0 {
+		delete(srv.subscribers, sub.userID)
+	}
+}
+
+func uniqueIDs(ids []string) []string {
+	seen := make(map[string]struct{}, len(ids))
+	result := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if _, ok := seen[id]; ok || id == "" {
+			continue
+		}
+		seen[id] = struct{}{}
+		result = append(result, id)
+	}
+	return result
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"time"
+
+	"github.com/DENFNC/web-test/internal/domain"
+	"github.com/jackc/pgx/v5"
+)
+
+// Notifier доставляет сообщения пользователям.
+type Notifier interface {
+	Notify(ctx context.Context, msg domain.Notification) error
+}
+
+type PasswordResetConfig struct {
+	CodeTTL     time.Duration
+	MaxAttempts int32
+}
+
+const resetCodeDigits = 8
+
+// WithPasswordReset включает восстановление пароля по одноразовым кодам.
+func WithPasswordReset(notifier Notifier, cfg PasswordResetConfig) AuthOption {
+	return func(srv *AuthService) {
+		srv.notifier = notifier
+		srv.reset = cfg
+	}
+}
+
+// RequestPasswordReset отправляет код восстановления на адрес пользователя.
+// Неизвестный логин и отсутствие адреса не считаются ошибкой, чтобы по
+// ответу нельзя было проверить, существует ли пользователь.
+func (srv *AuthService) RequestPasswordReset(ctx context.Context, login string) error {
+	const op = "service.AuthService.RequestPasswordReset"
+
+	log := srv.Logger.With("op", op)
+
+	if srv.notifier == nil {
+		return errors.New("password reset is not configured")
+	}
+
+	user, err := srv.repo.GetUserByLogin(ctx, login)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if user.Email == "" {
+		log.Info(
+			"Password reset requested for user without email",
+			slog.String("user_id", user.ID),
+		)
+		return nil
+	}
+
+	code, err := newResetCode()
+	if err != nil {
+		return err
+	}
+
+	if err := srv.repo.SavePasswordReset(ctx, user.ID, hashCode(code), time.Now().Add(srv.reset.CodeTTL)); err != nil {
+		log.Error(
+			"Failed to save password reset code",
+			slog.String("err", err.Error()),
+		)
+		return err
+	}
+
+	// Письмо отправляется в фоне: время ответа не должно зависеть от того,
+	// нашёлся ли пользователь.
+	msg := domain.Notification{
+		To:      user.Email,
+		Subject: "Password reset code",
+		Body: fmt.Sprintf(
+			"Your password reset code is %s.\nIt expires in %s.\n\nIf you did not request a password reset, ignore this message.\n",
+			code, srv.reset.CodeTTL,
+		),
+	}
+	go func(ctx context.Context) {
+		if err := srv.notifier.Notify(ctx, msg); err != nil {
+			log.Error(
+				"Failed to deliver password reset code",
+				slog.String("user_id", user.ID),
+				slog.String("err", err.Error()),
+			)
+		}
+	}(context.WithoutCancel(ctx))
+
+	return nil
+}
+
+// ConfirmPasswordReset меняет пароль по коду и завершает все сессии
+// пользователя.
+func (srv *AuthService) ConfirmPasswordReset(ctx context.Context, login, code, password string) error {
+	const op = "service.AuthService.ConfirmPasswordReset"
+
+	log := srv.Logger.With("op", op)
+
+	user, err := srv.repo.GetUserByLogin(ctx, login)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.ErrInvalidResetCode
+	}
+	if err != nil {
+		return err
+	}
+
+	hash, err := srv.hashPassword(ctx, password)
+	if err != nil {
+		return err
+	}
+
+	revoked, err := srv.repo.ResetPassword(ctx, user.ID, hashCode(code), hash, srv.reset.MaxAttempts)
+	if errors.Is(err, domain.ErrInvalidResetCode) {
+		return err
+	}
+	if err != nil {
+		log.Error(
+			"Failed to reset password",
+			slog.String("err", err.Error()),
+		)
+		return err
+	}
+
+	srv.forgetTokens(ctx, revoked...)
+	return nil
+}
+
+func newResetCode() (string, error) {
+	limit := new(big.Int).Exp(big.NewInt(10), big.NewInt(resetCodeDigits), nil)
+	n, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", resetCodeDigits, n), nil
+}
+
+func hashCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"log/slog"
+	"time"
+
+	"github.com/DENFNC/web-test/internal/domain"
+	"github.com/google/uuid"
+)
+
+const inviteCodeSize = 16
+
+// RegistrationConfig задаёт, кто может регистрироваться: все
+// (domain.RegistrationOpen), владельцы общего токена Token
+// (domain.RegistrationToken) или получатели приглашений администраторов
+// (domain.RegistrationInvite).
+type RegistrationConfig struct {
+	Mode      string
+	Token     string
+	InviteTTL time.Duration
+}
+
+func WithRegistration(cfg RegistrationConfig) AuthOption {
+	return func(srv *AuthService) {
+		srv.registration = cfg
+	}
+}
+
+// checkRegistrationToken проверяет общий токен регистрации. Для режима
+// приглашений код проверяется при сохранении пользователя.
+func (srv *AuthService) checkRegistrationToken(token string) error {
+	switch srv.registration.Mode {
+	case domain.RegistrationToken:
+		if token == "" {
+			return domain.ErrRegistrationTokenRequired
+		}
+		if subtle.ConstantTimeCompare([]byte(token), []byte(srv.registration.Token)) != 1 {
+			return domain.ErrInvalidRegistrationToken
+		}
+	case domain.RegistrationInvite:
+		if token == "" {
+			return domain.ErrInviteRequired
+		}
+	}
+	return nil
+}
+
+// CreateInvite создаёт приглашение; код возвращается только здесь.
+func (srv *AuthService) CreateInvite(ctx context.Context, adminID string) (*domain.Invite, error) {
+	const op = "service.AuthService.CreateInvite"
+
+	log := srv.Logger.With("op", op)
+
+	buf := make([]byte, inviteCodeSize)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	code := hex.EncodeToString(buf)
+
+	now := time.Now()
+	invite := &domain.Invite{
+		ID:        uuid.New().String(),
+		Code:      code,
+		CreatedBy: adminID,
+		ExpiresAt: now.Add(srv.registration.InviteTTL),
+		CreatedAt: now,
+	}
+	if err := srv.repo.SaveInvite(ctx, invite, hashCode(code)); err != nil {
+		log.Error(
+			"Failed to save invite",
+			slog.String("err", err.Error()),
+		)
+		return nil, err
+	}
+
+	return invite, nil
+}
+
+func (srv *AuthService) ListInvites(ctx context.Context) ([]domain.Invite, error) {
+	return srv.repo.ListInvites(ctx)
+}
+
+func (srv *AuthService) RevokeInvite(ctx context.Context, id string) error {
+	return srv.repo.RevokeInvite(ctx, id)
+}
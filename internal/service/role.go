@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/DENFNC/web-test/internal/domain"
+)
+
+// WithRoleCache кэширует роли, которые проверяются на каждом запросе. С
+// кэшем в памяти процесса смена роли доходит до других экземпляров
+// приложения не позже чем через ttl.
+func WithRoleCache(cache Cache, ttl time.Duration) AuthOption {
+	return func(srv *AuthService) {
+		srv.roleCache = cache
+		srv.roleTTL = ttl
+	}
+}
+
+func (srv *AuthService) UserRole(ctx context.Context, userID string) (string, error) {
+	key := roleCacheKey(userID)
+
+	if srv.roleCache != nil {
+		if data, ok, _ := srv.roleCache.Get(ctx, key); ok {
+			return string(data), nil
+		}
+	}
+
+	role, err := srv.repo.GetUserRole(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+
+	if srv.roleCache != nil && srv.roleTTL > 0 {
+		_ = srv.roleCache.Set(ctx, key, []byte(role), srv.roleTTL)
+	}
+	return role, nil
+}
+
+func (srv *AuthService) SetUserRole(ctx context.Context, userID, role string) error {
+	const op = "service.AuthService.SetUserRole"
+
+	log := srv.Logger.With("op", op)
+
+	if !domain.ValidRole(role) {
+		return domain.ErrInvalidRole
+	}
+
+	err := srv.repo.SetUserRole(ctx, userID, role)
+	if errors.Is(err, domain.ErrNotFound) {
+		return err
+	}
+	if err != nil {
+		log.Error(
+			"Failed to set user role",
+			slog.String("err", err.Error()),
+		)
+		return err
+	}
+
+	if srv.roleCache != nil {
+		if err := srv.roleCache.Delete(ctx, roleCacheKey(userID)); err != nil {
+			log.Warn(
+				"Failed to evict cached role",
+				slog.String("user_id", userID),
+				slog.String("err", err.Error()),
+			)
+		}
+	}
+
+	log.Info(
+		"User role changed",
+		slog.String("user_id", userID),
+		slog.String("role", role),
+	)
+	return nil
+}
+
+// BootstrapAdmins назначает роль администратора перечисленным пользователям.
+// Вызывается при запуске, чтобы у нового развёртывания был хотя бы один
+// администратор; отсутствующие пользователи пропускаются.
+func (srv *AuthService) BootstrapAdmins(ctx context.Context, userIDs []string) {
+	const op = "service.AuthService.BootstrapAdmins"
+
+	log := srv.Logger.With("op", op)
+
+	for _, userID := range userIDs {
+		role, err := srv.repo.GetUserRole(ctx, userID)
+		if err == nil && role == domain.RoleAdmin {
+			continue
+		}
+
+		if err := srv.SetUserRole(ctx, userID, domain.RoleAdmin); err != nil {
+			log.Warn(
+				"Failed to grant admin role",
+				slog.String("user_id", userID),
+				slog.String("err", err.Error()),
+			)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/DENFNC/web-test/internal/domain"
+	"github.com/DENFNC/web-test/internal/infra/scanner"
+)
+
+type memoryStorage map[string][]byte
+
+func (s memoryStorage) Save(name string, r io.Reader) (int64, error) {
+	data, err := io.ReadAll(r)
+	s[name] = data
+	return int64(len(data)), err
+}
+
+func (s memoryStorage) Open(name string) (io.ReadSeekCloser, error) {
+	return nopCloser{bytes.NewReader(s[name])}, nil
+}
+
+func (s memoryStorage) Remove(name string) error {
+	delete(s, name)
+	return nil
+}
+
+type nopCloser struct {
+	io.ReadSeeker
+}
+
+func (nopCloser) Close() error { return nil }
+
+// unreachableClamd возвращает адрес, на котором никто не слушает.
+func unreachableClamd(t *testing.T) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	return addr
+}
+
+func TestScanDocument_ClamdUnavailable(t *testing.T) {
+	tests := []struct {
+		name            string
+		failClosed      bool
+		wantQuarantined bool
+	}{
+		{name: "fail open", failClosed: false, wantQuarantined: false},
+		{name: "fail closed", failClosed: true, wantQuarantined: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := &DocumentService{
+				Logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+				Storage: memoryStorage{"file": []byte("content")},
+			}
+			WithScanner(scanner.NewClamdScanner(unreachableClamd(t), time.Second), tt.failClosed)(srv)
+
+			doc := &domain.Document{FileName: "file"}
+			srv.scanDocument(context.Background(), doc)
+
+			if doc.ScanStatus != domain.ScanStatusFailed {
+				t.Errorf("ScanStatus = %q, want %q", doc.ScanStatus, domain.ScanStatusFailed)
+			}
+			if doc.Quarantined != tt.wantQuarantined {
+				t.Errorf("Quarantined = %v, want %v", doc.Quarantined, tt.wantQuarantined)
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/DENFNC/web-test/internal/domain"
+	"github.com/DENFNC/web-test/internal/utils/totp"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	defaultTOTPIssuer = "web-srv"
+
+	// totpSkew — сколько соседних шагов принимается из-за расхождения часов.
+	totpSkew = 1
+
+	recoveryCodeCount  = 10
+	recoveryCodeLength = 10
+	recoveryAlphabet   = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+)
+
+// WithTOTPIssuer задаёт название сервиса, которое приложение-аутентификатор
+// показывает рядом с логином.
+func WithTOTPIssuer(issuer string) AuthOption {
+	return func(srv *AuthService) {
+		srv.totpIssuer = issuer
+	}
+}
+
+// EnrollTOTP выдаёт новый секрет. Второй фактор начнёт проверяться только
+// после ConfirmTOTP; повторный вызов до подтверждения заменяет секрет.
+func (srv *AuthService) EnrollTOTP(ctx context.Context, userID string) (*domain.TOTPEnrollment, error) {
+	const op = "service.AuthService.EnrollTOTP"
+
+	log := srv.Logger.With("op", op)
+
+	user, err := srv.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	err = srv.repo.SaveTOTPSecret(ctx, userID, secret)
+	if errors.Is(err, domain.ErrTOTPEnabled) {
+		return nil, err
+	}
+	if err != nil {
+		log.Error(
+			"Failed to save TOTP secret",
+			slog.String("err", err.Error()),
+		)
+		return nil, err
+	}
+
+	return &domain.TOTPEnrollment{
+		Secret: secret,
+		URI:    totp.URI(srv.totpIssuer, user.Login, secret),
+	}, nil
+}
+
+// ConfirmTOTP включает второй фактор по первому коду из приложения и
+// возвращает резервные коды. Они показываются один раз.
+func (srv *AuthService) ConfirmTOTP(ctx context.Context, userID, code string) ([]string, error) {
+	const op = "service.AuthService.ConfirmTOTP"
+
+	log := srv.Logger.With("op", op)
+
+	secret, err := srv.repo.GetTOTP(ctx, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !secret.EnabledAt.IsZero() {
+		return nil, domain.ErrTOTPEnabled
+	}
+
+	step, ok := totp.Validate(secret.Secret, code, time.Now(), totpSkew)
+	if !ok {
+		return nil, domain.ErrInvalidOTP
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for range recoveryCodeCount {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, hashCode(normalizeRecoveryCode(code)))
+	}
+
+	if err := srv.repo.EnableTOTP(ctx, userID, step, hashes); err != nil {
+		log.Error(
+			"Failed to enable TOTP",
+			slog.String("err", err.Error()),
+		)
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// DisableTOTP отключает второй фактор после проверки пароля.
+func (srv *AuthService) DisableTOTP(ctx context.Context, userID, password string) error {
+	creds, err := srv.repo.GetCredentialsByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	ok, err := srv.checkPassword(ctx, password, creds.Password)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return domain.ErrInvalidCredentials
+	}
+
+	return srv.repo.DisableTOTP(ctx, userID)
+}
+
+// verifySecondFactor проверяет код из приложения или резервный код, если у
+// пользователя включён второй фактор.
+func (srv *AuthService) verifySecondFactor(ctx context.Context, userID, otp string) error {
+	const op = "service.AuthService.verifySecondFactor"
+
+	log := srv.Logger.With("op", op)
+
+	secret, err := srv.repo.GetTOTP(ctx, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if secret.EnabledAt.IsZero() {
+		return nil
+	}
+
+	if otp == "" {
+		return domain.ErrOTPRequired
+	}
+
+	if len(otp) == totp.Digits && isDigits(otp) {
+		step, ok := totp.Validate(secret.Secret, otp, time.Now(), totpSkew)
+		if !ok {
+			return domain.ErrInvalidOTP
+		}
+
+		// Код принимается один раз: повторно предъявленный перехваченный
+		// код отклоняется.
+		ok, err = srv.repo.UseTOTPStep(ctx, userID, step)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return domain.ErrInvalidOTP
+		}
+		return nil
+	}
+
+	ok, err := srv.repo.UseRecoveryCode(ctx, userID, hashCode(normalizeRecoveryCode(otp)))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return domain.ErrInvalidOTP
+	}
+
+	log.Info(
+		"Recovery code used",
+		slog.String("user_id", userID),
+	)
+	return nil
+}
+
+// newRecoveryCode возвращает код вида XXXXX-XXXXX из алфавита без похожих
+// символов.
+func newRecoveryCode() (string, error) {
+	buf := make([]byte, recoveryCodeLength)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for i, c := range buf {
+		if i == recoveryCodeLength/2 {
+			b.WriteByte('-')
+		}
+		b.WriteByte(recoveryAlphabet[int(c)%len(recoveryAlphabet)])
+	}
+	return b.String(), nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToUpper(code)
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
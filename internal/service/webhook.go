@@ -0,0 +1,350 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/DENFNC/web-test/internal/domain"
+	"github.com/google/uuid"
+)
+
+const (
+	maxErrorLength     = 512
+	defaultDeliveryTTL = time.Minute
+
+	defaultDeliveryLimit = 50
+	maxDeliveryLimit     = 500
+)
+
+type WebhookRepository interface {
+	SaveWebhook(ctx context.Context, hook *domain.Webhook) error
+	ListWebhooks(ctx context.Context, ownerID string) ([]*domain.Webhook, error)
+	GetWebhook(ctx context.Context, id, ownerID string) (*domain.Webhook, error)
+	DeleteWebhook(ctx context.Context, id, ownerID string) error
+	EnqueueDeliveries(ctx context.Context, eventType string, userIDs []string, payload []byte) error
+	ClaimDeliveries(ctx context.Context, limit uint, lease time.Duration) ([]*domain.DeliveryTask, error)
+	CompleteDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error
+	ListDeliveries(ctx context.Context, webhookID string, limit, offset uint) ([]*domain.WebhookDelivery, error)
+}
+
+type WebhookConfig struct {
+	MaxAttempts int32
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	Timeout     time.Duration
+	// AllowPrivateNetworks разрешает доставку на внутренние адреса; только
+	// для разработки.
+	AllowPrivateNetworks bool
+}
+
+var (
+	errWebhookAddress   = errors.New("destination address is not allowed")
+	errUnexpectedStatus = errors.New("unexpected status")
+)
+
+type WebhookService struct {
+	*slog.Logger
+	repo   WebhookRepository
+	client *http.Client
+	cfg    WebhookConfig
+}
+
+func NewWebhookService(log *slog.Logger, repo WebhookRepository, cfg WebhookConfig) *WebhookService {
+	return &WebhookService{
+		Logger: log,
+		repo:   repo,
+		client: newWebhookClient(cfg),
+		cfg:    cfg,
+	}
+}
+
+func (srv *WebhookService) CreateWebhook(ctx context.Context, ownerID, url string, events []string) (*domain.Webhook, error) {
+	secret, err := generateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	hook := &domain.Webhook{
+		ID:       uuid.New().String(),
+		OwnerID:  ownerID,
+		URL:      url,
+		Secret:   secret,
+		Events:   events,
+		IsActive: true,
+	}
+	if err := srv.repo.SaveWebhook(ctx, hook); err != nil {
+		return nil, err
+	}
+
+	return hook, nil
+}
+
+func (srv *WebhookService) ListWebhooks(ctx context.Context, ownerID string) ([]*domain.Webhook, error) {
+	return srv.repo.ListWebhooks(ctx, ownerID)
+}
+
+func (srv *WebhookService) DeleteWebhook(ctx context.Context, id, ownerID string) error {
+	return srv.repo.DeleteWebhook(ctx, id, ownerID)
+}
+
+func (srv *WebhookService) ListDeliveries(ctx context.Context, id, ownerID string, limit, offset uint) ([]*domain.WebhookDelivery, error) {
+	if _, err := srv.repo.GetWebhook(ctx, id, ownerID); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case limit == 0:
+		limit = defaultDeliveryLimit
+	case limit > maxDeliveryLimit:
+		limit = maxDeliveryLimit
+	}
+
+	return srv.repo.ListDeliveries(ctx, id, limit, offset)
+}
+
+// Publish ставит событие в очередь доставки. Ошибка постановки не
+// прерывает операцию над документом и только логируется.
+func (srv *WebhookService) Publish(ctx context.Context, event *domain.DocumentEvent) {
+	const op = "service.WebhookService.Publish"
+
+	log := srv.Logger.With("op", op)
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Error(
+			"Failed to encode webhook payload",
+			slog.String("err", err.Error()),
+		)
+		return
+	}
+
+	err = srv.repo.EnqueueDeliveries(context.WithoutCancel(ctx), event.Type, event.Recipients(), payload)
+	if err != nil {
+		log.Error(
+			"Failed to enqueue webhook deliveries",
+			slog.String("event", event.Type),
+			slog.String("document_id", event.DocumentID),
+			slog.String("err", err.Error()),
+		)
+	}
+}
+
+// ProcessDeliveries отправляет пачку готовых доставок и возвращает их
+// количество.
+func (srv *WebhookService) ProcessDeliveries(ctx context.Context, limit uint) (int, error) {
+	const op = "service.WebhookService.ProcessDeliveries"
+
+	log := srv.Logger.With("op", op)
+
+	tasks, err := srv.repo.ClaimDeliveries(ctx, limit, srv.cfg.Timeout+defaultDeliveryTTL)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, task := range tasks {
+		delivery := srv.deliver(ctx, task)
+		if err := srv.repo.CompleteDelivery(ctx, delivery); err != nil {
+			log.Error(
+				"Failed to store delivery result",
+				slog.Int64("delivery_id", delivery.ID),
+				slog.String("err", err.Error()),
+			)
+		}
+	}
+
+	return len(tasks), nil
+}
+
+func (srv *WebhookService) deliver(ctx context.Context, task *domain.DeliveryTask) *domain.WebhookDelivery {
+	delivery := task.WebhookDelivery
+
+	status, err := srv.send(ctx, task)
+	delivery.LastStatusCode = int32(status)
+	if err == nil {
+		delivery.Status = domain.DeliverySucceeded
+		delivery.NextAttemptAt = time.Now()
+		return &delivery
+	}
+
+	delivery.LastError = deliveryError(err)
+	if len(delivery.LastError) > maxErrorLength {
+		delivery.LastError = delivery.LastError[:maxErrorLength]
+	}
+
+	if delivery.Attempts >= srv.cfg.MaxAttempts {
+		delivery.Status = domain.DeliveryFailed
+		delivery.NextAttemptAt = time.Now()
+		return &delivery
+	}
+
+	delivery.Status = domain.DeliveryPending
+	delivery.NextAttemptAt = time.Now().Add(srv.backoff(delivery.Attempts))
+	return &delivery
+}
+
+func (srv *WebhookService) send(ctx context.Context, task *domain.DeliveryTask) (int, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, task.URL, bytes.NewReader(task.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Event", task.EventType)
+	req.Header.Set("X-Webhook-Delivery", strconv.FormatInt(task.ID, 10))
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", "sha256="+Sign(task.Secret, timestamp, task.Payload))
+
+	resp, err := srv.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("%w %d", errUnexpectedStatus, resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// newWebhookClient возвращает клиент, который не ходит по перенаправлениям
+// и не соединяется с внутренними адресами. Адрес проверяется после
+// разрешения имени, непосредственно перед соединением, поэтому его не обойти
+// DNS-записью, указывающей внутрь сети.
+func newWebhookClient(cfg WebhookConfig) *http.Client {
+	dialer := &net.Dialer{Timeout: cfg.Timeout}
+	if !cfg.AllowPrivateNetworks {
+		dialer.Control = denyPrivateAddress
+	}
+
+	return &http.Client{
+		Timeout: cfg.Timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: cfg.Timeout,
+			MaxIdleConns:        100,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// deniedPrefixes — специальные диапазоны из реестров IANA, которые
+// IsGlobalUnicast не отсекает: частные, общий адрес провайдера,
+// документационные, тестовые, зарезервированные и адреса трансляции.
+var deniedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("192.88.99.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("::/96"),
+	netip.MustParsePrefix("::ffff:0:0:0/96"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+	netip.MustParsePrefix("100::/64"),
+	netip.MustParsePrefix("2001::/23"),
+	netip.MustParsePrefix("2001:db8::/32"),
+	netip.MustParsePrefix("2002::/16"),
+	netip.MustParsePrefix("3fff::/20"),
+	netip.MustParsePrefix("5f00::/16"),
+}
+
+// nat64Prefix — общеизвестный префикс NAT64: в последних 32 битах адреса
+// лежит IPv4-адрес получателя, он и проверяется.
+var nat64Prefix = netip.MustParsePrefix("64:ff9b::/96")
+
+func denyPrivateAddress(_, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return errWebhookAddress
+	}
+	if !allowedWebhookAddr(addrPort.Addr()) {
+		return errWebhookAddress
+	}
+	return nil
+}
+
+func allowedWebhookAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if nat64Prefix.Contains(addr) {
+		ip := addr.As16()
+		addr = netip.AddrFrom4([4]byte(ip[12:]))
+	}
+
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range deniedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// deliveryError возвращает описание ошибки для истории доставок. Ошибки
+// соединения не раскрывают подробностей: иначе по истории можно было бы
+// выяснять, какие адреса и порты доступны серверу.
+func deliveryError(err error) string {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, errWebhookAddress):
+		return errWebhookAddress.Error()
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return "request timed out"
+	case errors.Is(err, errUnexpectedStatus):
+		return err.Error()
+	default:
+		return "request failed"
+	}
+}
+
+// backoff возвращает задержку перед следующей попыткой: BaseBackoff,
+// удваиваемый на каждой попытке, но не больше MaxBackoff.
+func (srv *WebhookService) backoff(attempts int32) time.Duration {
+	delay := srv.cfg.BaseBackoff
+	for i := int32(1); i < attempts && delay < srv.cfg.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, srv.cfg.MaxBackoff)
+}
+
+// Sign вычисляет HMAC-SHA256 от "timestamp.body". Получатель проверяет
+// подпись тем же секретом, а временную метку — на давность.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func generateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
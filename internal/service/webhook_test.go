@@ -0,0 +1,69 @@
+package service
+
+import (
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestDenyPrivateAddress(t *testing.T) {
+	tests := []struct {
+		ip      string
+		allowed bool
+	}{
+		{"93.184.216.34", true},
+		{"8.8.8.8", true},
+		{"2606:4700::1111", true},
+		{"::ffff:93.184.216.34", true},
+		{"64:ff9b::5db8:d822", true},
+
+		{"127.0.0.1", false},
+		{"0.0.0.0", false},
+		{"0.1.2.3", false},
+		{"10.0.0.1", false},
+		{"100.64.0.1", false},
+		{"100.127.255.254", false},
+		{"169.254.169.254", false},
+		{"172.16.0.1", false},
+		{"192.0.0.8", false},
+		{"192.0.2.1", false},
+		{"192.168.1.1", false},
+		{"198.18.0.1", false},
+		{"198.19.255.254", false},
+		{"198.51.100.1", false},
+		{"203.0.113.1", false},
+		{"224.0.0.1", false},
+		{"240.0.0.1", false},
+		{"255.255.255.255", false},
+
+		{"::", false},
+		{"::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:169.254.169.254", false},
+		{"::ffff:0:10.0.0.1", false},
+		{"::10.0.0.1", false},
+		{"64:ff9b::7f00:1", false},
+		{"64:ff9b::a9fe:a9fe", false},
+		{"64:ff9b:1::5db8:d822", false},
+		{"100::1", false},
+		{"2001::1", false},
+		{"2001:db8::1", false},
+		{"2002:7f00:1::1", false},
+		{"fc00::1", false},
+		{"fd00::1", false},
+		{"fe80::1", false},
+		{"ff02::1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			err := denyPrivateAddress("tcp", net.JoinHostPort(tt.ip, "443"), nil)
+			if tt.allowed && err != nil {
+				t.Errorf("denyPrivateAddress(%s) = %v, want nil", tt.ip, err)
+			}
+			if !tt.allowed && !errors.Is(err, errWebhookAddress) {
+				t.Errorf("denyPrivateAddress(%s) = %v, want errWebhookAddress", tt.ip, err)
+			}
+		})
+	}
+}
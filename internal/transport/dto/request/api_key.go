@@ -0,0 +1,16 @@
+package request
+
+import "time"
+
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" validate:"required,max=64"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1,dive,oneof=docs:read docs:write docs:share tokens:revoked"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// AllowedIPs — адреса или сети в нотации CIDR; пустой список разрешает
+	// любые адреса.
+	AllowedIPs []string `json:"allowed_ips,omitempty" validate:"max=32,dive,required,max=64"`
+}
+
+func (req *CreateAPIKeyRequest) Validate() error {
+	return validate.Struct(req)
+}
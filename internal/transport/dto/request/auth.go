@@ -3,8 +3,61 @@ package request
 type AuthUserRequest struct {
 	Login    string `json:"login" validate:"required,min=8,alphanum"`
 	Password string `json:"password" validate:"required,min=8,password"`
+	// OTP — код из приложения-аутентификатора или резервный код.
+	OTP string `json:"otp,omitempty" validate:"omitempty,max=32"`
 }
 
 func (req *AuthUserRequest) Validate() error {
 	return validate.Struct(req)
 }
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=8,password"`
+}
+
+func (req *ChangePasswordRequest) Validate() error {
+	return validate.Struct(req)
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+func (req *RefreshTokenRequest) Validate() error {
+	return validate.Struct(req)
+}
+
+type PasswordResetRequest struct {
+	Login string `json:"login" validate:"required"`
+}
+
+func (req *PasswordResetRequest) Validate() error {
+	return validate.Struct(req)
+}
+
+type PasswordResetConfirmRequest struct {
+	Login       string `json:"login" validate:"required"`
+	Code        string `json:"code" validate:"required,numeric"`
+	NewPassword string `json:"new_password" validate:"required,min=8,password"`
+}
+
+func (req *PasswordResetConfirmRequest) Validate() error {
+	return validate.Struct(req)
+}
+
+type ConfirmTOTPRequest struct {
+	Code string `json:"code" validate:"required,numeric,len=6"`
+}
+
+func (req *ConfirmTOTPRequest) Validate() error {
+	return validate.Struct(req)
+}
+
+type DisableTOTPRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+func (req *DisableTOTPRequest) Validate() error {
+	return validate.Struct(req)
+}
@@ -1,10 +1,37 @@
 package request
 
+import "time"
+
 type DocumentMetaRequest struct {
-	Name   string   `json:"name"`
-	File   bool     `json:"file"`
-	Public bool     `json:"public"`
-	Token  string   `json:"token"`
-	Mime   string   `json:"mime"`
-	Grant  []string `json:"grant"`
+	Name      string     `json:"name"`
+	File      bool       `json:"file"`
+	Public    bool       `json:"public"`
+	Token     string     `json:"token"`
+	Mime      string     `json:"mime"`
+	Grant     []string   `json:"grant"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type UpdateDocumentRequest struct {
+	Mime      *string    `json:"mime"`
+	Public    *bool      `json:"public"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type LockDocumentRequest struct {
+	TTLSeconds int64 `json:"ttl_seconds" validate:"gte=0"`
+}
+
+func (req *LockDocumentRequest) Validate() error {
+	return validate.Struct(req)
+}
+
+type TransferDocumentRequest struct {
+	Login      string `json:"login" validate:"required"`
+	KeepAccess bool   `json:"keep_access"`
+	Force      bool   `json:"force"`
+}
+
+func (req *TransferDocumentRequest) Validate() error {
+	return validate.Struct(req)
 }
@@ -20,9 +20,11 @@ var validate = func() *validator.Validate {
 }()
 
 type RegisterUserRequest struct {
-	Token    string `json:"token,omitempty"`
+	// Token — токен регистрации или код приглашения, если регистрация закрыта.
+	Token    string `json:"token,omitempty" validate:"max=256"`
 	Login    string `json:"login" validate:"required,min=8,alphanum"`
 	Password string `json:"password" validate:"required,min=8,password"`
+	Email    string `json:"email,omitempty" validate:"omitempty,email,max=254"`
 }
 
 func (req *RegisterUserRequest) Validate() error {
@@ -0,0 +1,9 @@
+package request
+
+type SetUserRoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=admin user read-only service"`
+}
+
+func (req *SetUserRoleRequest) Validate() error {
+	return validate.Struct(req)
+}
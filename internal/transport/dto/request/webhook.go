@@ -0,0 +1,10 @@
+package request
+
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" validate:"required,http_url"`
+	Events []string `json:"events" validate:"required,min=1,dive,oneof=document.created document.updated document.deleted document.transferred access.granted"`
+}
+
+func (req *CreateWebhookRequest) Validate() error {
+	return validate.Struct(req)
+}
@@ -0,0 +1,18 @@
+package response
+
+import "time"
+
+type AuditEvent struct {
+	ID         int64     `json:"id"`
+	ActorID    string    `json:"actor_id,omitempty"`
+	DocumentID string    `json:"document_id,omitempty"`
+	TargetID   string    `json:"target_id,omitempty"`
+	Action     string    `json:"action"`
+	IP         string    `json:"ip,omitempty"`
+	UserAgent  string    `json:"user_agent,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type AuditEventsResponse struct {
+	Events []AuditEvent `json:"events"`
+}
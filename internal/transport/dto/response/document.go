@@ -1,10 +1,52 @@
 package response
 
+import "time"
+
 type DocumentUploadData struct {
-	JSON map[string]interface{} `json:"json,omitempty"`
-	File string                 `json:"file"`
+	JSON        map[string]interface{} `json:"json,omitempty"`
+	File        string                 `json:"file"`
+	Quarantined bool                   `json:"quarantined,omitempty"`
 }
 
 type DocumentUploadResponse struct {
 	Data DocumentUploadData `json:"data"`
 }
+
+type Document struct {
+	ID          string     `json:"id"`
+	FileName    string     `json:"file_name"`
+	MimeType    string     `json:"mime_type"`
+	HasFile     bool       `json:"has_file"`
+	IsPublic    bool       `json:"is_public"`
+	OwnerID     string     `json:"owner_id"`
+	Size        int64      `json:"size"`
+	ScanStatus  string     `json:"scan_status"`
+	Quarantined bool       `json:"quarantined"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	Version     int64      `json:"version"`
+	ETag        string     `json:"etag"`
+}
+
+type DocumentsResponse struct {
+	Documents []Document `json:"documents"`
+}
+
+type DocumentLock struct {
+	DocumentID string    `json:"document_id"`
+	UserID     string    `json:"user_id"`
+	AcquiredAt time.Time `json:"acquired_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+type DocumentTransfer struct {
+	DocumentID string    `json:"document_id"`
+	FromUserID string    `json:"from_user_id"`
+	ToUserID   string    `json:"to_user_id"`
+	KeepAccess bool      `json:"keep_access"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type DocumentTransfersResponse struct {
+	Transfers []DocumentTransfer `json:"transfers"`
+}
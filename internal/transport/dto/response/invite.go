@@ -0,0 +1,17 @@
+package response
+
+import "time"
+
+type Invite struct {
+	ID        string     `json:"id"`
+	Code      string     `json:"code,omitempty"`
+	CreatedBy string     `json:"created_by,omitempty"`
+	UsedBy    string     `json:"used_by,omitempty"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type InvitesResponse struct {
+	Invites []Invite `json:"invites"`
+}
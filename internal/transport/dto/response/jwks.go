@@ -0,0 +1,28 @@
+package response
+
+import "time"
+
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+type RevokedToken struct {
+	ID        string    `json:"jti"`
+	SessionID string    `json:"sid,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type RevokedTokens struct {
+	Tokens []RevokedToken `json:"revoked"`
+}
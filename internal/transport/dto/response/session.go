@@ -0,0 +1,16 @@
+package response
+
+import "time"
+
+type Session struct {
+	ID         string     `json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	IP         string     `json:"ip,omitempty"`
+	UserAgent  string     `json:"user_agent,omitempty"`
+	Current    bool       `json:"current"`
+}
+
+type SessionsResponse struct {
+	Sessions []Session `json:"sessions"`
+}
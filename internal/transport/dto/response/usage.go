@@ -0,0 +1,10 @@
+package response
+
+type UsageResponse struct {
+	BytesUsed          int64  `json:"bytes_used"`
+	BytesQuota         int64  `json:"bytes_quota"`
+	BytesRemaining     *int64 `json:"bytes_remaining"`
+	DocumentsCount     int64  `json:"documents_count"`
+	DocumentsQuota     int64  `json:"documents_quota"`
+	DocumentsRemaining *int64 `json:"documents_remaining"`
+}
@@ -0,0 +1,6 @@
+package response
+
+type UserRole struct {
+	UserID string `json:"user_id"`
+	Role   string `json:"role"`
+}
@@ -0,0 +1,221 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/DENFNC/web-test/internal/domain"
+	"github.com/DENFNC/web-test/internal/transport/dto/request"
+	"github.com/DENFNC/web-test/internal/transport/dto/response"
+	"github.com/google/uuid"
+)
+
+type APIKeyService interface {
+	CreateAPIKey(ctx context.Context, userID string, key *domain.APIKey) (*domain.APIKey, error)
+	ListAPIKeys(ctx context.Context, userID string) ([]domain.APIKey, error)
+	RevokeAPIKey(ctx context.Context, userID, id string) error
+}
+
+// APIKeyHandler управляет API-ключами: своими — по /api/me/api-keys, ключами
+// любого пользователя, например сервисной учётной записи, — по
+// /api/admin/users/{id}/api-keys с разрешением apikey:manage.
+type APIKeyHandler struct {
+	*slog.Logger
+	APIKeyService
+	Audit AuditRecorder
+}
+
+func NewAPIKeyHandler(log *slog.Logger, mux *http.ServeMux, srv APIKeyService, audit AuditRecorder) {
+	handler := &APIKeyHandler{
+		Logger:        log,
+		APIKeyService: srv,
+		Audit:         audit,
+	}
+
+	mux.HandleFunc("POST /api/me/api-keys", handler.createOwnKey)
+	mux.HandleFunc("GET /api/me/api-keys", handler.listOwnKeys)
+	mux.HandleFunc("DELETE /api/me/api-keys/{key}", handler.revokeOwnKey)
+	mux.HandleFunc("POST /api/admin/users/{id}/api-keys", handler.createUserKey)
+	mux.HandleFunc("GET /api/admin/users/{id}/api-keys", handler.listUserKeys)
+	mux.HandleFunc("DELETE /api/admin/users/{id}/api-keys/{key}", handler.revokeUserKey)
+}
+
+func (api *APIKeyHandler) createOwnKey(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticate(w, r)
+	if !ok {
+		return
+	}
+	api.createKey(w, r, userID, userID)
+}
+
+func (api *APIKeyHandler) listOwnKeys(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticate(w, r)
+	if !ok {
+		return
+	}
+	api.listKeys(w, r, userID)
+}
+
+func (api *APIKeyHandler) revokeOwnKey(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticate(w, r)
+	if !ok {
+		return
+	}
+	api.revokeKey(w, r, userID, userID)
+}
+
+func (api *APIKeyHandler) createUserKey(w http.ResponseWriter, r *http.Request) {
+	adminID, userID, ok := api.requireKeyManager(w, r)
+	if !ok {
+		return
+	}
+	api.createKey(w, r, adminID, userID)
+}
+
+func (api *APIKeyHandler) listUserKeys(w http.ResponseWriter, r *http.Request) {
+	_, userID, ok := api.requireKeyManager(w, r)
+	if !ok {
+		return
+	}
+	api.listKeys(w, r, userID)
+}
+
+func (api *APIKeyHandler) revokeUserKey(w http.ResponseWriter, r *http.Request) {
+	adminID, userID, ok := api.requireKeyManager(w, r)
+	if !ok {
+		return
+	}
+	api.revokeKey(w, r, adminID, userID)
+}
+
+// requireKeyManager пропускает только вход пользователя с разрешением
+// apikey:manage и возвращает его id и id пользователя из пути.
+func (api *APIKeyHandler) requireKeyManager(w http.ResponseWriter, r *http.Request) (string, string, bool) {
+	principal, ok := requireUser(w, r)
+	if !ok {
+		return "", "", false
+	}
+	if !principal.Can(domain.PermAPIKeyManage) {
+		response.Error(w, http.StatusForbidden, "insufficient permissions")
+		return "", "", false
+	}
+
+	userID := r.PathValue("id")
+	if _, err := uuid.Parse(userID); err != nil {
+		response.Error(w, http.StatusNotFound, "user not found")
+		return "", "", false
+	}
+	return principal.UserID, userID, true
+}
+
+func (api *APIKeyHandler) createKey(w http.ResponseWriter, r *http.Request, actorID, userID string) {
+	var req request.CreateAPIKeyRequest
+	if !decodeAndValidate(w, r, &req) {
+		return
+	}
+
+	key := &domain.APIKey{
+		Name:            req.Name,
+		Scopes:          req.Scopes,
+		AllowedNetworks: req.AllowedIPs,
+	}
+	if req.ExpiresAt != nil {
+		if !req.ExpiresAt.After(time.Now()) {
+			response.Error(w, http.StatusBadRequest, "expires_at must be in the future")
+			return
+		}
+		key.ExpiresAt = *req.ExpiresAt
+	}
+
+	created, err := api.APIKeyService.CreateAPIKey(r.Context(), userID, key)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			response.Error(w, http.StatusNotFound, "user not found")
+		case errors.Is(err, domain.ErrInvalidScope), errors.Is(err, domain.ErrInvalidNetwork):
+			response.Error(w, http.StatusBadRequest, err.Error())
+		default:
+			response.Error(w, http.StatusInternalServerError, "cannot create api key")
+		}
+		return
+	}
+
+	event := newAuditEvent(r, domain.AuditAPIKeyCreate, actorID, "")
+	event.TargetID = userID
+	api.Audit.Record(r.Context(), event)
+
+	response.JSON(w, http.StatusCreated, response.CreateAPIKeyResponse{
+		APIKey: toAPIKeyResponse(*created),
+		Key:    created.Key,
+	})
+}
+
+func (api *APIKeyHandler) listKeys(w http.ResponseWriter, r *http.Request, userID string) {
+	keys, err := api.APIKeyService.ListAPIKeys(r.Context(), userID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "cannot list api keys")
+		return
+	}
+
+	resp := response.APIKeysResponse{APIKeys: make([]response.APIKey, 0, len(keys))}
+	for _, key := range keys {
+		resp.APIKeys = append(resp.APIKeys, toAPIKeyResponse(key))
+	}
+	response.JSON(w, http.StatusOK, resp)
+}
+
+func (api *APIKeyHandler) revokeKey(w http.ResponseWriter, r *http.Request, actorID, userID string) {
+	id := r.PathValue("key")
+	if _, err := uuid.Parse(id); err != nil {
+		response.Error(w, http.StatusNotFound, "api key not found")
+		return
+	}
+
+	err := api.APIKeyService.RevokeAPIKey(r.Context(), userID, id)
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		response.Error(w, http.StatusNotFound, "api key not found")
+		return
+	case err != nil:
+		response.Error(w, http.StatusInternalServerError, "cannot revoke api key")
+		return
+	}
+
+	event := newAuditEvent(r, domain.AuditAPIKeyRevoke, actorID, "")
+	event.TargetID = userID
+	api.Audit.Record(r.Context(), event)
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"response": map[string]bool{
+			id: true,
+		},
+	})
+}
+
+func toAPIKeyResponse(key domain.APIKey) response.APIKey {
+	resp := response.APIKey{
+		ID:         key.ID,
+		UserID:     key.UserID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		AllowedIPs: key.AllowedNetworks,
+		LastUsedIP: key.LastUsedIP,
+		CreatedAt:  key.CreatedAt,
+	}
+	if resp.AllowedIPs == nil {
+		resp.AllowedIPs = []string{}
+	}
+	if !key.ExpiresAt.IsZero() {
+		expiresAt := key.ExpiresAt
+		resp.ExpiresAt = &expiresAt
+	}
+	if !key.LastUsedAt.IsZero() {
+		lastUsedAt := key.LastUsedAt
+		resp.LastUsedAt = &lastUsedAt
+	}
+	return resp
+}
@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/DENFNC/web-test/internal/domain"
+	"github.com/DENFNC/web-test/internal/transport/dto/response"
+	"github.com/DENFNC/web-test/internal/utils/mapping"
+)
+
+type AuditService interface {
+	AuditRecorder
+	ListEvents(ctx context.Context, filter domain.AuditFilter) ([]*domain.AuditEvent, error)
+}
+
+type AuditDocuments interface {
+	GetDocumentByID(ctx context.Context, id string) (*domain.Document, error)
+}
+
+type AuditHandler struct {
+	*slog.Logger
+	AuditService
+	Docs AuditDocuments
+}
+
+func NewAuditHandler(log *slog.Logger, mux *http.ServeMux, srv AuditService, docs AuditDocuments) {
+	handler := &AuditHandler{
+		Logger:       log,
+		AuditService: srv,
+		Docs:         docs,
+	}
+
+	mux.HandleFunc("GET /api/docs/{id}/audit", handler.documentAuditHandler)
+	mux.HandleFunc("GET /api/admin/audit", handler.adminAuditHandler)
+}
+
+func (api *AuditHandler) documentAuditHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		response.Error(w, http.StatusBadRequest, "missing document id")
+		return
+	}
+
+	userID, ok := authorize(w, r, domain.PermDocumentRead)
+	if !ok {
+		return
+	}
+
+	doc, err := api.Docs.GetDocumentByID(r.Context(), id)
+	if err != nil {
+		response.Error(w, http.StatusNotFound, "document not found")
+		return
+	}
+
+	if doc.OwnerID != userID {
+		if !can(r, domain.PermDocumentOverride) {
+			response.Error(w, http.StatusForbidden, "access denied")
+			return
+		}
+		api.AuditService.Record(r.Context(), newAuditEvent(r, domain.AuditAdminOverride, userID, doc.ID))
+	}
+
+	filter, ok := parseAuditFilter(w, r)
+	if !ok {
+		return
+	}
+	filter.DocumentID = doc.ID
+
+	api.writeEvents(w, r, filter)
+}
+
+func (api *AuditHandler) adminAuditHandler(w http.ResponseWriter, r *http.Request) {
+	if _, ok := authorize(w, r, domain.PermAuditRead); !ok {
+		return
+	}
+
+	filter, ok := parseAuditFilter(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	filter.ActorID = query.Get("actor_id")
+	filter.DocumentID = query.Get("document_id")
+
+	api.writeEvents(w, r, filter)
+}
+
+func (api *AuditHandler) writeEvents(w http.ResponseWriter, r *http.Request, filter domain.AuditFilter) {
+	events, err := api.AuditService.ListEvents(r.Context(), filter)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "cannot list audit events")
+		return
+	}
+
+	resp := response.AuditEventsResponse{
+		Events: make([]response.AuditEvent, 0, len(events)),
+	}
+	for _, event := range events {
+		var item response.AuditEvent
+		if err := mapping.MapStruct(event, &item); err != nil {
+			response.Error(w, http.StatusInternalServerError, "cannot map audit event")
+			return
+		}
+		resp.Events = append(resp.Events, item)
+	}
+
+	response.JSON(w, http.StatusOK, resp)
+}
+
+func parseAuditFilter(w http.ResponseWriter, r *http.Request) (domain.AuditFilter, bool) {
+	query := r.URL.Query()
+	filter := domain.AuditFilter{
+		Action: query.Get("action"),
+	}
+
+	var err error
+	if v := query.Get("from"); v != "" {
+		if filter.From, err = time.Parse(time.RFC3339, v); err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid from")
+			return filter, false
+		}
+	}
+	if v := query.Get("to"); v != "" {
+		if filter.To, err = time.Parse(time.RFC3339, v); err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid to")
+			return filter, false
+		}
+	}
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid limit")
+			return filter, false
+		}
+		filter.Limit = uint(limit)
+	}
+	if v := query.Get("offset"); v != "" {
+		offset, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid offset")
+			return filter, false
+		}
+		filter.Offset = uint(offset)
+	}
+
+	return filter, true
+}
@@ -3,8 +3,11 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/DENFNC/web-test/internal/domain"
 	"github.com/DENFNC/web-test/internal/transport/dto/request"
@@ -13,9 +16,19 @@ import (
 )
 
 type AuthService interface {
-	CreateUser(ctx context.Context, user *domain.User) (string, error)
-	LoginUser(ctx context.Context, user *domain.User) (string, error)
-	RevokeToken(ctx context.Context, token string) error
+	CreateUser(ctx context.Context, user *domain.User, token string) (string, error)
+	LoginUser(ctx context.Context, user *domain.User, otp string, info domain.SessionInfo) (*domain.TokenPair, error)
+	RefreshTokens(ctx context.Context, refreshToken string, info domain.SessionInfo) (*domain.TokenPair, error)
+	ListSessions(ctx context.Context, userID, currentToken string) ([]domain.Session, error)
+	RevokeSession(ctx context.Context, userID, sessionID string) error
+	RevokeAllSessions(ctx context.Context, userID string) error
+	RevokeToken(ctx context.Context, userID, token string) error
+	ChangePassword(ctx context.Context, principal *domain.Principal, current, password string) error
+	RequestPasswordReset(ctx context.Context, login string) error
+	ConfirmPasswordReset(ctx context.Context, login, code, password string) error
+	EnrollTOTP(ctx context.Context, userID string) (*domain.TOTPEnrollment, error)
+	ConfirmTOTP(ctx context.Context, userID, code string) ([]string, error)
+	DisableTOTP(ctx context.Context, userID, password string) error
 }
 
 type AuthHandler struct {
@@ -32,7 +45,17 @@ func NewAuthHandler(log *slog.Logger, mux *http.ServeMux, srv AuthService) {
 	{
 		mux.HandleFunc("POST /api/register", handler.register)
 		mux.HandleFunc("POST /api/auth", handler.auth)
+		mux.HandleFunc("POST /api/auth/refresh", handler.refresh)
 		mux.HandleFunc("DELETE /api/auth/{token}", handler.exit)
+		mux.HandleFunc("POST /api/me/password", handler.changePassword)
+		mux.HandleFunc("POST /api/auth/password/reset", handler.requestPasswordReset)
+		mux.HandleFunc("POST /api/auth/password/reset/confirm", handler.confirmPasswordReset)
+		mux.HandleFunc("POST /api/me/2fa/totp", handler.enrollTOTP)
+		mux.HandleFunc("POST /api/me/2fa/totp/confirm", handler.confirmTOTP)
+		mux.HandleFunc("DELETE /api/me/2fa/totp", handler.disableTOTP)
+		mux.HandleFunc("GET /api/sessions", handler.listSessions)
+		mux.HandleFunc("DELETE /api/sessions", handler.revokeAllSessions)
+		mux.HandleFunc("DELETE /api/sessions/{id}", handler.revokeSession)
 	}
 }
 
@@ -48,8 +71,18 @@ func (api *AuthHandler) register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	login, err := api.AuthService.CreateUser(r.Context(), &user)
-	if err != nil {
+	login, err := api.AuthService.CreateUser(r.Context(), &user, req.Token)
+	if retryLater(w, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, domain.ErrRegistrationTokenRequired), errors.Is(err, domain.ErrInviteRequired):
+		response.Error(w, http.StatusUnauthorized, err.Error())
+		return
+	case errors.Is(err, domain.ErrInvalidRegistrationToken), errors.Is(err, domain.ErrInvalidInvite):
+		response.Error(w, http.StatusForbidden, err.Error())
+		return
+	case err != nil:
 		response.Error(w, http.StatusBadRequest, "User creation failed")
 		return
 	}
@@ -69,24 +102,122 @@ func (api *AuthHandler) auth(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := api.AuthService.LoginUser(r.Context(), &user)
-	if err != nil {
-		response.Error(w, http.StatusUnauthorized, "Login failed")
+	pair, err := api.AuthService.LoginUser(r.Context(), &user, req.OTP, sessionInfo(r))
+	if retryLater(w, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, domain.ErrInvalidCredentials):
+		response.Error(w, http.StatusUnauthorized, "invalid login or password")
+		return
+	case errors.Is(err, domain.ErrOTPRequired), errors.Is(err, domain.ErrInvalidOTP):
+		response.Error(w, http.StatusUnauthorized, err.Error())
+		return
+	case err != nil:
+		response.Error(w, http.StatusInternalServerError, "Login failed")
 		return
 	}
 
-	response.JSON(w, http.StatusOK, response.AuthUserResponse{Token: token})
+	response.JSON(w, http.StatusOK, toAuthUserResponse(pair))
 }
 
+func (api *AuthHandler) refresh(w http.ResponseWriter, r *http.Request) {
+	var req request.RefreshTokenRequest
+	if !decodeAndValidate(w, r, &req) {
+		return
+	}
+
+	pair, err := api.AuthService.RefreshTokens(r.Context(), req.RefreshToken, sessionInfo(r))
+	switch {
+	case errors.Is(err, domain.ErrInvalidToken), errors.Is(err, domain.ErrTokenReused):
+		response.Error(w, http.StatusUnauthorized, "invalid refresh token")
+		return
+	case err != nil:
+		response.Error(w, http.StatusInternalServerError, "cannot refresh token")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, toAuthUserResponse(pair))
+}
+
+// changePassword меняет пароль и завершает остальные сессии пользователя.
+func (api *AuthHandler) changePassword(w http.ResponseWriter, r *http.Request) {
+	principal, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req request.ChangePasswordRequest
+	if !decodeAndValidate(w, r, &req) {
+		return
+	}
+
+	err := api.AuthService.ChangePassword(r.Context(), principal, req.CurrentPassword, req.NewPassword)
+	if retryLater(w, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, domain.ErrInvalidCredentials):
+		response.Error(w, http.StatusForbidden, "invalid current password")
+		return
+	case err != nil:
+		response.Error(w, http.StatusInternalServerError, "cannot change password")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"response": map[string]bool{
+			principal.UserID: true,
+		},
+	})
+}
+
+// retryLater отвечает 429 при ограничении входа и 503 при переполненной
+// очереди на проверку паролей; в обоих случаях с Retry-After.
+func retryLater(w http.ResponseWriter, err error) bool {
+	var throttled *domain.ThrottleError
+	switch {
+	case errors.As(err, &throttled):
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+		response.Error(w, http.StatusTooManyRequests, throttled.Error())
+		return true
+	case errors.Is(err, domain.ErrServerBusy):
+		w.Header().Set("Retry-After", "1")
+		response.Error(w, http.StatusServiceUnavailable, err.Error())
+		return true
+	}
+	return false
+}
+
+func toAuthUserResponse(pair *domain.TokenPair) response.AuthUserResponse {
+	return response.AuthUserResponse{
+		Token:            pair.AccessToken,
+		ExpiresAt:        pair.AccessExpiresAt,
+		RefreshToken:     pair.RefreshToken,
+		RefreshExpiresAt: pair.RefreshExpiresAt,
+	}
+}
+
+// exit завершает сессию, к которой относится токен из пути. Отозвать можно
+// только собственный токен.
 func (api *AuthHandler) exit(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticate(w, r)
+	if !ok {
+		return
+	}
+
 	token := r.PathValue("token")
 	if token == "" {
 		response.Error(w, http.StatusBadRequest, "missing token")
 		return
 	}
 
-	err := api.AuthService.RevokeToken(r.Context(), token)
+	err := api.AuthService.RevokeToken(r.Context(), userID, token)
 	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			response.Error(w, http.StatusNotFound, "token not found")
+			return
+		}
 		response.Error(w, http.StatusInternalServerError, "cannot revoke token")
 		return
 	}
@@ -0,0 +1,286 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/DENFNC/web-test/internal/domain"
+	"github.com/DENFNC/web-test/internal/transport/dto/response"
+)
+
+type TokenValidator interface {
+	ValidateToken(ctx context.Context, token string) (string, error)
+}
+
+// RoleResolver возвращает текущую роль пользователя.
+type RoleResolver interface {
+	UserRole(ctx context.Context, userID string) (string, error)
+}
+
+type AuditRecorder interface {
+	Record(ctx context.Context, event *domain.AuditEvent)
+}
+
+// maxMultipartMemory — сколько multipart-формы держится в памяти, остальное
+// уходит во временные файлы.
+const maxMultipartMemory = 32 << 20
+
+type principalKey struct{}
+
+// authResult — итог проверки учётных данных запроса; err заполняется, если
+// токен передан, но не принят.
+type authResult struct {
+	principal *domain.Principal
+	err       error
+}
+
+var errLegacyToken = errors.New("token must be sent in the Authorization header")
+
+// APIKeyValidator проверяет API-ключ и адрес, с которого он предъявлен.
+type APIKeyValidator interface {
+	ValidateAPIKey(ctx context.Context, key, ip string) (*domain.APIKey, error)
+}
+
+// Authenticate определяет вызывающего по заголовку Authorization: Bearer и
+// кладёт результат в контекст запроса. В заголовке принимаются и токены
+// доступа, и API-ключи. До legacySunset (нулевое значение — без ограничения)
+// принимаются и прежние способы передачи токена: параметр token и поле token
+// в meta при загрузке; ответы на такие запросы получают заголовки
+// Deprecation и Sunset. Роль вызывающего определяется при каждом запросе,
+// поэтому её смена действует и для уже выданных токенов. Форма загрузки
+// разбирается до проверки токена, поэтому её размер ограничен maxUploadBytes.
+func Authenticate(validator TokenValidator, keys APIKeyValidator, roles RoleResolver, legacySunset time.Time, maxUploadBytes int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, legacy, err := requestToken(w, r, maxUploadBytes)
+			if err != nil {
+				writeFormError(w, err)
+				return
+			}
+			if token == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			result := &authResult{}
+			switch {
+			case legacy && !legacySunset.IsZero() && time.Now().After(legacySunset):
+				result.err = errLegacyToken
+			default:
+				if legacy {
+					w.Header().Set("Deprecation", "true")
+					if !legacySunset.IsZero() {
+						w.Header().Set("Sunset", legacySunset.UTC().Format(http.TimeFormat))
+					}
+				}
+
+				result.principal, result.err = resolvePrincipal(r, validator, keys, roles, token, legacy)
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, result)))
+		})
+	}
+}
+
+// resolvePrincipal проверяет API-ключ или токен доступа и определяет роль.
+// API-ключи принимаются только в заголовке. Значение с префиксом ключа,
+// не найденное среди ключей, проверяется как токен: случайный токен может
+// начинаться с тех же символов.
+func resolvePrincipal(r *http.Request, validator TokenValidator, keys APIKeyValidator, roles RoleResolver, token string, legacy bool) (*domain.Principal, error) {
+	ctx := r.Context()
+
+	var principal *domain.Principal
+	if !legacy && strings.HasPrefix(token, domain.APIKeyPrefix) {
+		key, err := keys.ValidateAPIKey(ctx, token, clientIP(r))
+		switch {
+		case err == nil:
+			principal = &domain.Principal{
+				UserID:   key.UserID,
+				APIKeyID: key.ID,
+				Scopes:   domain.ScopePermissions(key.Scopes),
+			}
+		case !errors.Is(err, domain.ErrInvalidToken):
+			return nil, err
+		}
+	}
+
+	if principal == nil {
+		userID, err := validator.ValidateToken(ctx, token)
+		if err != nil {
+			return nil, err
+		}
+		principal = &domain.Principal{UserID: userID, Token: token}
+	}
+
+	role, err := roles.UserRole(ctx, principal.UserID)
+	if err != nil {
+		return nil, err
+	}
+	principal.Role = role
+	return principal, nil
+}
+
+// requestToken возвращает токен запроса и признак того, что он передан
+// устаревшим способом. Поле token в meta ищется только в загрузке
+// POST /api/docs.
+func requestToken(w http.ResponseWriter, r *http.Request, maxUploadBytes int64) (string, bool, error) {
+	if scheme, credentials, ok := strings.Cut(r.Header.Get("Authorization"), " "); ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(credentials), false, nil
+	}
+
+	if isLegacyDocumentRoute(r) {
+		if token := r.URL.Query().Get("token"); token != "" {
+			return token, true, nil
+		}
+	}
+
+	if r.Method != http.MethodPost || r.URL.Path != "/api/docs" {
+		return "", false, nil
+	}
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "multipart/form-data" {
+		return "", false, nil
+	}
+
+	if err := parseUploadForm(w, r, maxUploadBytes); err != nil {
+		return "", false, err
+	}
+
+	var meta struct {
+		Token string `json:"token"`
+	}
+	if json.Unmarshal([]byte(r.FormValue("meta")), &meta) == nil && meta.Token != "" {
+		return meta.Token, true, nil
+	}
+	return "", false, nil
+}
+
+// isLegacyDocumentRoute сообщает, принимал ли маршрут токен в ?token= до
+// появления заголовка Authorization: это только получение и удаление
+// документа.
+func isLegacyDocumentRoute(r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodDelete {
+		return false
+	}
+	id, ok := strings.CutPrefix(r.URL.Path, "/api/docs/")
+	return ok && id != "" && !strings.Contains(id, "/")
+}
+
+// parseUploadForm разбирает multipart-форму, ограничивая размер тела.
+// Повторный вызов ничего не делает.
+func parseUploadForm(w http.ResponseWriter, r *http.Request, maxBytes int64) error {
+	if r.MultipartForm != nil {
+		return nil
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+	return r.ParseMultipartForm(maxMultipartMemory)
+}
+
+func writeFormError(w http.ResponseWriter, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		response.Error(w, http.StatusRequestEntityTooLarge, "request body too large")
+		return
+	}
+	response.Error(w, http.StatusBadRequest, "Error multipart data")
+}
+
+func principalFromContext(ctx context.Context) (*domain.Principal, error) {
+	result, ok := ctx.Value(principalKey{}).(*authResult)
+	if !ok {
+		return nil, nil
+	}
+	return result.principal, result.err
+}
+
+// authenticate отвечает 401, если токен не передан, и 403, если он не
+// принят или вместо токена передан API-ключ.
+func authenticate(w http.ResponseWriter, r *http.Request) (string, bool) {
+	principal, ok := requireUser(w, r)
+	if !ok {
+		return "", false
+	}
+	return principal.UserID, true
+}
+
+// requireUser не принимает API-ключи: управление учётной записью, сессиями
+// и самими ключами требует входа пользователя.
+func requireUser(w http.ResponseWriter, r *http.Request) (*domain.Principal, bool) {
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return nil, false
+	}
+	if principal.APIKeyID != "" {
+		response.Error(w, http.StatusForbidden, "api keys cannot be used for this endpoint")
+		return nil, false
+	}
+	return principal, true
+}
+
+func requirePrincipal(w http.ResponseWriter, r *http.Request) (*domain.Principal, bool) {
+	principal, err := principalFromContext(r.Context())
+	switch {
+	case errors.Is(err, errLegacyToken):
+		response.Error(w, http.StatusUnauthorized, err.Error())
+		return nil, false
+	case errors.Is(err, domain.ErrInvalidToken):
+		response.Error(w, http.StatusForbidden, "invalid token")
+		return nil, false
+	case errors.Is(err, domain.ErrAPIKeyIPNotAllowed):
+		response.Error(w, http.StatusForbidden, err.Error())
+		return nil, false
+	case err != nil:
+		response.Error(w, http.StatusInternalServerError, "cannot validate token")
+		return nil, false
+	case principal == nil:
+		response.Error(w, http.StatusUnauthorized, "missing token")
+		return nil, false
+	}
+	return principal, true
+}
+
+// authorize как authenticate, но принимает и API-ключи и дополнительно
+// отвечает 403, если роли вызывающего или областям ключа не хватает
+// разрешения perm.
+func authorize(w http.ResponseWriter, r *http.Request, perm domain.Permission) (string, bool) {
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return "", false
+	}
+	if !principal.Can(perm) {
+		response.Error(w, http.StatusForbidden, "insufficient permissions")
+		return "", false
+	}
+	return principal.UserID, true
+}
+
+// can сообщает, есть ли у уже аутентифицированного вызывающего разрешение
+// perm.
+func can(r *http.Request, perm domain.Permission) bool {
+	principal, err := principalFromContext(r.Context())
+	return err == nil && principal != nil && principal.Can(perm)
+}
+
+func newAuditEvent(r *http.Request, action, actorID, documentID string) *domain.AuditEvent {
+	return &domain.AuditEvent{
+		ActorID:    actorID,
+		DocumentID: documentID,
+		Action:     action,
+		IP:         clientIP(r),
+		UserAgent:  r.UserAgent(),
+	}
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
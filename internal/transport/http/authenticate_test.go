@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestToken_LegacyQuery(t *testing.T) {
+	tests := []struct {
+		method, target string
+		legacy         bool
+	}{
+		{http.MethodGet, "/api/docs/doc-1?token=t", true},
+		{http.MethodDelete, "/api/docs/doc-1?token=t", true},
+		{http.MethodGet, "/api/docs?token=t", false},
+		{http.MethodPatch, "/api/docs/doc-1?token=t", false},
+		{http.MethodGet, "/api/docs/doc-1/audit?token=t", false},
+		{http.MethodGet, "/api/events?token=t", false},
+		{http.MethodGet, "/.well-known/revoked?token=t", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.target, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+
+			token, legacy, err := requestToken(httptest.NewRecorder(), req, 1<<20)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := map[bool]string{true: "t"}[tt.legacy]; token != want || legacy != tt.legacy {
+				t.Errorf("requestToken() = %q, %v, want %q, %v", token, legacy, want, tt.legacy)
+			}
+		})
+	}
+}
@@ -2,18 +2,21 @@ package handler
 
 import (
 	"context"
-	"fmt"
-	"io"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"log/slog"
+	"mime"
 	"net/http"
-	"os"
-	"path/filepath"
-	"strings"
+	"strconv"
+	"time"
 
 	"github.com/DENFNC/web-test/internal/domain"
 	"github.com/DENFNC/web-test/internal/service"
+	"github.com/DENFNC/web-test/internal/transport/dto/request"
 	"github.com/DENFNC/web-test/internal/transport/dto/response"
 	"github.com/DENFNC/web-test/internal/utils"
+	"github.com/jackc/pgx/v5"
 )
 
 type DocumentService interface{}
@@ -21,23 +24,46 @@ type DocumentService interface{}
 type DocumentHandler struct {
 	*slog.Logger
 	Service *service.DocumentService
+	Audit   AuditRecorder
+
+	maxUploadBytes int64
 }
 
-func NewDocumentHandler(log *slog.Logger, mux *http.ServeMux, docService *service.DocumentService) {
+func NewDocumentHandler(log *slog.Logger, mux *http.ServeMux, docService *service.DocumentService, audit AuditRecorder, maxUploadBytes int64) {
 	handler := &DocumentHandler{
-		Logger:  log,
-		Service: docService,
+		Logger:         log,
+		Service:        docService,
+		Audit:          audit,
+		maxUploadBytes: maxUploadBytes,
 	}
 
 	mux.HandleFunc("POST /api/docs", handler.createDocumentHandler)
 	mux.HandleFunc("GET /api/docs", handler.getDocumentsHandler)
 	mux.HandleFunc("GET /api/docs/{id}", handler.getDocumentHandler)
+	mux.HandleFunc("HEAD /api/docs/{id}", handler.headDocumentHandler)
 	mux.HandleFunc("DELETE /api/docs/{id}", handler.deleteDocumentHandler)
+	mux.HandleFunc("PATCH /api/docs/{id}", handler.updateDocumentHandler)
+	mux.HandleFunc("PUT /api/docs/{id}/content", handler.updateContentHandler)
+	mux.HandleFunc("POST /api/docs/{id}/lock", handler.lockDocumentHandler)
+	mux.HandleFunc("GET /api/docs/{id}/lock", handler.getLockHandler)
+	mux.HandleFunc("DELETE /api/docs/{id}/lock", handler.unlockDocumentHandler)
+	mux.HandleFunc("POST /api/docs/{id}/copy", handler.copyDocumentHandler)
+	mux.HandleFunc("POST /api/docs/{id}/transfer", handler.transferDocumentHandler)
+	mux.HandleFunc("GET /api/docs/{id}/transfer", handler.getTransferHandler)
+	mux.HandleFunc("DELETE /api/docs/{id}/transfer", handler.cancelTransferHandler)
+	mux.HandleFunc("POST /api/docs/{id}/transfer/accept", handler.acceptTransferHandler)
+	mux.HandleFunc("GET /api/me/usage", handler.getUsageHandler)
+	mux.HandleFunc("GET /api/me/transfers", handler.listTransfersHandler)
 }
 
 func (api *DocumentHandler) createDocumentHandler(w http.ResponseWriter, r *http.Request) {
-	if err := r.ParseMultipartForm(32 << 20); err != nil {
-		response.Error(w, http.StatusBadRequest, "Error multipart data")
+	ownerID, ok := authorize(w, r, domain.PermDocumentWrite)
+	if !ok {
+		return
+	}
+
+	if err := parseUploadForm(w, r, api.maxUploadBytes); err != nil {
+		writeFormError(w, err)
 		return
 	}
 
@@ -47,9 +73,13 @@ func (api *DocumentHandler) createDocumentHandler(w http.ResponseWriter, r *http
 		return
 	}
 
-	ownerID, err := api.Service.ValidateToken(r.Context(), meta.Token)
-	if err != nil {
-		response.Error(w, http.StatusUnauthorized, err.Error())
+	if len(meta.Grant) > 0 && !can(r, domain.PermDocumentShare) {
+		response.Error(w, http.StatusForbidden, "insufficient permissions to share documents")
+		return
+	}
+
+	if meta.ExpiresAt != nil && !meta.ExpiresAt.After(time.Now()) {
+		response.Error(w, http.StatusBadRequest, "expires_at must be in the future")
 		return
 	}
 
@@ -61,90 +91,107 @@ func (api *DocumentHandler) createDocumentHandler(w http.ResponseWriter, r *http
 	defer file.Close()
 
 	originalName := fileHeader.Filename
-	docID, uuidFileName, err := api.Service.SaveDocument(r.Context(), *meta, ownerID, originalName)
+	doc, err := api.Service.SaveDocument(r.Context(), *meta, ownerID, originalName, fileHeader.Size, file)
 	if err != nil {
+		if errors.Is(err, domain.ErrQuotaExceeded) {
+			response.Error(w, http.StatusRequestEntityTooLarge, err.Error())
+			return
+		}
 		response.Error(w, http.StatusInternalServerError, "cannot save document")
 		return
 	}
 
-	uploadPath := filepath.Join("data/uploads", uuidFileName)
-	out, err := os.Create(uploadPath)
-	if err != nil {
-		fmt.Println(err)
-		response.Error(w, http.StatusInternalServerError, "cannot create file")
-		return
-	}
-	defer out.Close()
-
-	if _, err := io.Copy(out, file); err != nil {
-		response.Error(w, http.StatusInternalServerError, "cannot write file")
-		return
-	}
+	api.Audit.Record(r.Context(), newAuditEvent(r, domain.AuditDocumentUpload, ownerID, doc.ID))
 
 	userIDs := findUserIDs(r.Context(), api, meta.Grant)
-	if err := api.Service.AddDocumentAccess(r.Context(), docID, userIDs); err != nil {
+	if err := api.Service.AddDocumentAccess(r.Context(), doc, userIDs); err != nil {
 		response.Error(w, http.StatusInternalServerError, "cannot save document access")
 		return
 	}
 
+	for _, userID := range userIDs {
+		event := newAuditEvent(r, domain.AuditAccessGrant, ownerID, doc.ID)
+		event.TargetID = userID
+		api.Audit.Record(r.Context(), event)
+	}
+
 	jsonData := parseOptionalJSON(r, api)
 
 	response.JSON(w, http.StatusOK, response.DocumentUploadResponse{
 		Data: response.DocumentUploadData{
-			File: originalName,
-			JSON: jsonData,
+			File:        originalName,
+			JSON:        jsonData,
+			Quarantined: doc.Quarantined,
 		},
 	})
 }
 
 func (api *DocumentHandler) getDocumentsHandler(w http.ResponseWriter, r *http.Request) {
-	// TODO: Логика получения списка документов
-}
-
-func (api *DocumentHandler) getDocumentHandler(w http.ResponseWriter, r *http.Request) {
-	id := r.PathValue("id")
-	if id == "" {
-		response.Error(w, http.StatusBadRequest, "missing document id")
+	userID, ok := authorize(w, r, domain.PermDocumentRead)
+	if !ok {
 		return
 	}
 
-	doc, err := api.Service.GetDocumentByID(r.Context(), id)
+	filter := domain.DocumentFilter{UserID: userID}
+	query := r.URL.Query()
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid limit")
+			return
+		}
+		filter.Limit = uint(limit)
+	}
+	if v := query.Get("offset"); v != "" {
+		offset, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid offset")
+			return
+		}
+		filter.Offset = uint(offset)
+	}
+
+	docs, err := api.Service.ListDocuments(r.Context(), filter)
 	if err != nil {
-		response.Error(w, http.StatusNotFound, "document not found")
+		response.Error(w, http.StatusInternalServerError, "cannot list documents")
 		return
 	}
 
-	token := r.URL.Query().Get("token")
+	resp := response.DocumentsResponse{
+		Documents: make([]response.Document, 0, len(docs)),
+	}
+	for _, doc := range docs {
+		resp.Documents = append(resp.Documents, toDocumentResponse(doc))
+	}
 
-	fmt.Println(token)
+	response.JSON(w, http.StatusOK, resp)
+}
 
-	if token == "" {
-		response.Error(w, http.StatusUnauthorized, "missing token")
+func (api *DocumentHandler) getDocumentHandler(w http.ResponseWriter, r *http.Request) {
+	doc, userID, ok := api.readableDocument(w, r, domain.PermDocumentRead)
+	if !ok {
 		return
 	}
-	userID, err := api.Service.ValidateToken(r.Context(), token)
-	if err != nil {
-		response.Error(w, http.StatusForbidden, "invalid token")
+
+	if !setDocumentHeaders(w, r, doc) {
 		return
 	}
-
-	if !canUserAccessDocument(r.Context(), api, doc, userID) {
-		response.Error(w, http.StatusForbidden, "access denied")
+	if notModified(r, documentETag(doc), doc.UpdatedAt) {
+		w.WriteHeader(http.StatusNotModified)
 		return
 	}
 
-	if doc.HasFile {
-		filePath := filepath.Join("data/uploads", doc.FileName)
-		cleanPath := filepath.Clean(filePath)
+	api.Audit.Record(r.Context(), newAuditEvent(r, domain.AuditDocumentDownload, userID, doc.ID))
 
-		if rel, err := filepath.Rel("data/uploads", cleanPath); err != nil || strings.HasPrefix(rel, "..") {
-			response.Error(w, http.StatusForbidden, "invalid file path")
+	if doc.HasFile {
+		file, err := api.Service.OpenDocumentFile(doc)
+		if err != nil {
+			response.Error(w, http.StatusNotFound, "file not found")
 			return
 		}
+		defer file.Close()
 
-		w.Header().Set("Content-Type", doc.MimeType)
-		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, doc.FileName))
-		http.ServeFile(w, r, cleanPath)
+		http.ServeContent(w, r, doc.FileName, doc.UpdatedAt, file)
 		return
 	}
 
@@ -153,6 +200,89 @@ func (api *DocumentHandler) getDocumentHandler(w http.ResponseWriter, r *http.Re
 	})
 }
 
+// headDocumentHandler отдаёт те же заголовки, что и getDocumentHandler,
+// не читая файл и не записывая скачивание в журнал.
+func (api *DocumentHandler) headDocumentHandler(w http.ResponseWriter, r *http.Request) {
+	doc, _, ok := api.readableDocument(w, r, domain.PermDocumentRead)
+	if !ok {
+		return
+	}
+
+	if !setDocumentHeaders(w, r, doc) {
+		return
+	}
+	if notModified(r, documentETag(doc), doc.UpdatedAt) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	if doc.HasFile {
+		w.Header().Set("Content-Length", strconv.FormatInt(doc.Size, 10))
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// readableDocument проверяет доступ к документу и то, что его можно отдать
+// клиенту.
+func (api *DocumentHandler) readableDocument(w http.ResponseWriter, r *http.Request, perm domain.Permission) (*domain.Document, string, bool) {
+	doc, userID, ok := api.accessibleDocument(w, r, perm)
+	if !ok {
+		return nil, "", false
+	}
+
+	if doc.Quarantined {
+		response.Error(w, http.StatusForbidden, "document is quarantined")
+		return nil, "", false
+	}
+
+	return doc, userID, true
+}
+
+// setDocumentHeaders выставляет заголовки скачивания. Параметр disposition
+// позволяет открыть файл в браузере вместо сохранения.
+func setDocumentHeaders(w http.ResponseWriter, r *http.Request, doc *domain.Document) bool {
+	disposition := r.URL.Query().Get("disposition")
+	switch disposition {
+	case "":
+		disposition = "attachment"
+	case "inline", "attachment":
+	default:
+		response.Error(w, http.StatusBadRequest, "invalid disposition")
+		return false
+	}
+
+	header := w.Header()
+	header.Set("ETag", documentETag(doc))
+	if !doc.UpdatedAt.IsZero() {
+		header.Set("Last-Modified", doc.UpdatedAt.UTC().Format(http.TimeFormat))
+	}
+
+	if !doc.HasFile {
+		return true
+	}
+
+	header.Set("Accept-Ranges", "bytes")
+	if doc.MimeType != "" {
+		header.Set("Content-Type", doc.MimeType)
+	}
+	header.Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{
+		"filename": doc.FileName,
+	}))
+
+	// Repr-Digest описывает весь файл, Content-Digest — тело ответа, поэтому
+	// он совпадает с первым только без Range.
+	if sum, err := hex.DecodeString(doc.Checksum); err == nil && len(sum) > 0 {
+		digest := "sha-256=:" + base64.StdEncoding.EncodeToString(sum) + ":"
+		header.Set("Repr-Digest", digest)
+		if r.Header.Get("Range") == "" {
+			header.Set("Content-Digest", digest)
+		}
+	}
+	return true
+}
+
 func (api *DocumentHandler) deleteDocumentHandler(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 	if id == "" {
@@ -160,14 +290,8 @@ func (api *DocumentHandler) deleteDocumentHandler(w http.ResponseWriter, r *http
 		return
 	}
 
-	token := r.URL.Query().Get("token")
-	if token == "" {
-		response.Error(w, http.StatusUnauthorized, "missing token")
-		return
-	}
-	userID, err := api.Service.ValidateToken(r.Context(), token)
-	if err != nil {
-		response.Error(w, http.StatusForbidden, "invalid token")
+	userID, ok := authorize(w, r, domain.PermDocumentWrite)
+	if !ok {
 		return
 	}
 
@@ -177,17 +301,28 @@ func (api *DocumentHandler) deleteDocumentHandler(w http.ResponseWriter, r *http
 		return
 	}
 
-	if doc.OwnerID != userID {
+	if !api.ownerOrOverride(r, doc, userID) {
 		response.Error(w, http.StatusForbidden, "access denied")
 		return
 	}
 
-	err = api.Service.DeleteDocument(r.Context(), id)
+	version, ok := ifMatchVersion(w, r, doc)
+	if !ok {
+		return
+	}
+
+	err = api.Service.DeleteDocument(r.Context(), id, version)
 	if err != nil {
+		if errors.Is(err, domain.ErrVersionMismatch) {
+			response.Error(w, http.StatusPreconditionFailed, err.Error())
+			return
+		}
 		response.Error(w, http.StatusInternalServerError, "cannot delete document")
 		return
 	}
 
+	api.Audit.Record(r.Context(), newAuditEvent(r, domain.AuditDocumentDelete, userID, id))
+
 	response.JSON(w, http.StatusOK, map[string]any{
 		"response": map[string]bool{
 			id: true,
@@ -195,6 +330,205 @@ func (api *DocumentHandler) deleteDocumentHandler(w http.ResponseWriter, r *http
 	})
 }
 
+func (api *DocumentHandler) updateDocumentHandler(w http.ResponseWriter, r *http.Request) {
+	doc, userID, ok := api.writableDocument(w, r)
+	if !ok {
+		return
+	}
+
+	var req request.UpdateDocumentRequest
+	if !decodeAndValidate(w, r, &req) {
+		return
+	}
+
+	if (req.Public != nil || req.ExpiresAt != nil) && !api.ownerOrOverride(r, doc, userID) {
+		response.Error(w, http.StatusForbidden, "only the owner can change visibility or expiry")
+		return
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		response.Error(w, http.StatusBadRequest, "expires_at must be in the future")
+		return
+	}
+
+	version, ok := ifMatchVersion(w, r, doc)
+	if !ok {
+		return
+	}
+
+	updated, err := api.Service.UpdateDocumentMeta(r.Context(), doc.ID, userID, version, domain.DocumentPatch{
+		MimeType:  req.Mime,
+		IsPublic:  req.Public,
+		ExpiresAt: req.ExpiresAt,
+	})
+	if err != nil {
+		writeUpdateError(w, err)
+		return
+	}
+
+	api.Audit.Record(r.Context(), newAuditEvent(r, domain.AuditDocumentUpdate, userID, doc.ID))
+
+	w.Header().Set("ETag", documentETag(updated))
+	response.JSON(w, http.StatusOK, toDocumentResponse(updated))
+}
+
+func (api *DocumentHandler) updateContentHandler(w http.ResponseWriter, r *http.Request) {
+	doc, userID, ok := api.writableDocument(w, r)
+	if !ok {
+		return
+	}
+	defer r.Body.Close()
+
+	version, ok := ifMatchVersion(w, r, doc)
+	if !ok {
+		return
+	}
+
+	updated, err := api.Service.UpdateDocumentContent(
+		r.Context(),
+		doc,
+		userID,
+		version,
+		r.Header.Get("Content-Type"),
+		r.ContentLength,
+		r.Body,
+	)
+	if err != nil {
+		writeUpdateError(w, err)
+		return
+	}
+
+	api.Audit.Record(r.Context(), newAuditEvent(r, domain.AuditDocumentUpdate, userID, doc.ID))
+
+	w.Header().Set("ETag", documentETag(updated))
+	response.JSON(w, http.StatusOK, toDocumentResponse(updated))
+}
+
+// accessibleDocument проверяет токен, разрешение perm и доступ к документу
+// из пути запроса.
+func (api *DocumentHandler) accessibleDocument(w http.ResponseWriter, r *http.Request, perm domain.Permission) (*domain.Document, string, bool) {
+	return api.documentFromPath(w, r, perm, canUserAccessDocument)
+}
+
+// writableDocument как accessibleDocument, но пропускает только владельца и
+// получивших доступ: публичный документ читать может любой, а менять и
+// блокировать — нет.
+func (api *DocumentHandler) writableDocument(w http.ResponseWriter, r *http.Request) (*domain.Document, string, bool) {
+	return api.documentFromPath(w, r, domain.PermDocumentWrite, canUserWriteDocument)
+}
+
+func (api *DocumentHandler) documentFromPath(
+	w http.ResponseWriter,
+	r *http.Request,
+	perm domain.Permission,
+	allowed func(ctx context.Context, api *DocumentHandler, doc *domain.Document, userID string) bool,
+) (*domain.Document, string, bool) {
+	id := r.PathValue("id")
+	if id == "" {
+		response.Error(w, http.StatusBadRequest, "missing document id")
+		return nil, "", false
+	}
+
+	userID, ok := authorize(w, r, perm)
+	if !ok {
+		return nil, "", false
+	}
+
+	doc, err := api.Service.GetDocumentByID(r.Context(), id)
+	if err != nil {
+		response.Error(w, http.StatusNotFound, "document not found")
+		return nil, "", false
+	}
+
+	if !allowed(r.Context(), api, doc, userID) && !api.override(r, doc, userID) {
+		response.Error(w, http.StatusForbidden, "access denied")
+		return nil, "", false
+	}
+
+	return doc, userID, true
+}
+
+// ownerOrOverride пропускает владельца документа и, через override, тех,
+// кому разрешён доступ к любому документу.
+func (api *DocumentHandler) ownerOrOverride(r *http.Request, doc *domain.Document, userID string) bool {
+	return doc.OwnerID == userID || api.override(r, doc, userID)
+}
+
+// override разрешает доступ в обход владельца и выданных прав, если у
+// вызывающего есть document:override, и записывает это в журнал аудита.
+func (api *DocumentHandler) override(r *http.Request, doc *domain.Document, userID string) bool {
+	if !can(r, domain.PermDocumentOverride) {
+		return false
+	}
+	api.Audit.Record(r.Context(), newAuditEvent(r, domain.AuditAdminOverride, userID, doc.ID))
+	return true
+}
+
+func writeUpdateError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrVersionMismatch):
+		response.Error(w, http.StatusPreconditionFailed, err.Error())
+	case errors.Is(err, domain.ErrLocked):
+		response.Error(w, http.StatusLocked, err.Error())
+	case errors.Is(err, domain.ErrQuotaExceeded):
+		response.Error(w, http.StatusRequestEntityTooLarge, err.Error())
+	case errors.Is(err, pgx.ErrNoRows):
+		response.Error(w, http.StatusNotFound, "document not found")
+	default:
+		response.Error(w, http.StatusInternalServerError, "cannot update document")
+	}
+}
+
+func toDocumentResponse(doc *domain.Document) response.Document {
+	resp := response.Document{
+		ID:          doc.ID,
+		FileName:    doc.FileName,
+		MimeType:    doc.MimeType,
+		HasFile:     doc.HasFile,
+		IsPublic:    doc.IsPublic,
+		OwnerID:     doc.OwnerID,
+		Size:        doc.Size,
+		ScanStatus:  doc.ScanStatus,
+		Quarantined: doc.Quarantined,
+		CreatedAt:   doc.CreatedAt,
+		Version:     doc.Version,
+		ETag:        documentETag(doc),
+	}
+	if !doc.ExpiresAt.IsZero() {
+		resp.ExpiresAt = &doc.ExpiresAt
+	}
+	return resp
+}
+
+func (api *DocumentHandler) getUsageHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authorize(w, r, domain.PermDocumentRead)
+	if !ok {
+		return
+	}
+
+	usage, err := api.Service.GetUsage(r.Context(), userID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "cannot get usage")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.UsageResponse{
+		BytesUsed:          usage.BytesUsed,
+		BytesQuota:         usage.QuotaBytes,
+		BytesRemaining:     remaining(usage.QuotaBytes, usage.BytesUsed),
+		DocumentsCount:     usage.DocumentsCount,
+		DocumentsQuota:     usage.QuotaDocuments,
+		DocumentsRemaining: remaining(usage.QuotaDocuments, usage.DocumentsCount),
+	})
+}
+
+func remaining(quota, used int64) *int64 {
+	if quota <= 0 {
+		return nil
+	}
+	left := max(quota-used, 0)
+	return &left
+}
+
 func findUserIDs(ctx context.Context, api *DocumentHandler, logins []string) []string {
 	var userIDs []string
 	for _, login := range logins {
@@ -227,3 +561,14 @@ func canUserAccessDocument(ctx context.Context, api *DocumentHandler, doc *domai
 	}
 	return false
 }
+
+func canUserWriteDocument(ctx context.Context, api *DocumentHandler, doc *domain.Document, userID string) bool {
+	if userID == "" {
+		return false
+	}
+	if doc.OwnerID == userID {
+		return true
+	}
+	ok, _ := api.Service.HasDocumentAccess(ctx, doc.ID, userID)
+	return ok
+}
@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/DENFNC/web-test/internal/domain"
+	"github.com/DENFNC/web-test/internal/transport/dto/request"
+	"github.com/DENFNC/web-test/internal/transport/dto/response"
+)
+
+func (api *DocumentHandler) lockDocumentHandler(w http.ResponseWriter, r *http.Request) {
+	doc, userID, ok := api.writableDocument(w, r)
+	if !ok {
+		return
+	}
+
+	var req request.LockDocumentRequest
+	if r.ContentLength != 0 && !decodeAndValidate(w, r, &req) {
+		return
+	}
+
+	lock, err := api.Service.LockDocument(r.Context(), doc.ID, userID, time.Duration(req.TTLSeconds)*time.Second)
+	if err != nil {
+		if errors.Is(err, domain.ErrLocked) {
+			response.JSON(w, http.StatusLocked, toLockResponse(lock))
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, "cannot lock document")
+		return
+	}
+
+	api.Audit.Record(r.Context(), newAuditEvent(r, domain.AuditDocumentLock, userID, doc.ID))
+
+	response.JSON(w, http.StatusOK, toLockResponse(lock))
+}
+
+func (api *DocumentHandler) getLockHandler(w http.ResponseWriter, r *http.Request) {
+	doc, _, ok := api.accessibleDocument(w, r, domain.PermDocumentRead)
+	if !ok {
+		return
+	}
+
+	lock, err := api.Service.GetDocumentLock(r.Context(), doc.ID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			response.Error(w, http.StatusNotFound, "document is not locked")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, "cannot get lock")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, toLockResponse(lock))
+}
+
+// unlockDocumentHandler снимает собственную блокировку. Владелец документа
+// или администратор может принудительно снять чужую блокировку параметром
+// force=true.
+func (api *DocumentHandler) unlockDocumentHandler(w http.ResponseWriter, r *http.Request) {
+	doc, userID, ok := api.writableDocument(w, r)
+	if !ok {
+		return
+	}
+
+	force := r.URL.Query().Get("force") == "true"
+	if force && !api.ownerOrOverride(r, doc, userID) {
+		response.Error(w, http.StatusForbidden, "only the owner can break a lock")
+		return
+	}
+
+	if err := api.Service.UnlockDocument(r.Context(), doc.ID, userID, force); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			response.Error(w, http.StatusConflict, "no lock held by the caller")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, "cannot unlock document")
+		return
+	}
+
+	api.Audit.Record(r.Context(), newAuditEvent(r, domain.AuditDocumentUnlock, userID, doc.ID))
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"response": map[string]bool{
+			doc.ID: true,
+		},
+	})
+}
+
+func toLockResponse(lock *domain.DocumentLock) response.DocumentLock {
+	return response.DocumentLock{
+		DocumentID: lock.DocumentID,
+		UserID:     lock.UserID,
+		AcquiredAt: lock.AcquiredAt,
+		ExpiresAt:  lock.ExpiresAt,
+	}
+}
@@ -0,0 +1,252 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/DENFNC/web-test/internal/domain"
+	"github.com/DENFNC/web-test/internal/transport/dto/request"
+	"github.com/DENFNC/web-test/internal/transport/dto/response"
+	"github.com/DENFNC/web-test/internal/utils/mapping"
+	"github.com/jackc/pgx/v5"
+)
+
+func (api *DocumentHandler) copyDocumentHandler(w http.ResponseWriter, r *http.Request) {
+	src, userID, ok := api.readableDocument(w, r, domain.PermDocumentWrite)
+	if !ok {
+		return
+	}
+
+	doc, err := api.Service.CopyDocument(r.Context(), src.ID, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrQuotaExceeded):
+			response.Error(w, http.StatusRequestEntityTooLarge, err.Error())
+		case errors.Is(err, pgx.ErrNoRows):
+			response.Error(w, http.StatusNotFound, "document not found")
+		default:
+			response.Error(w, http.StatusInternalServerError, "cannot copy document")
+		}
+		return
+	}
+
+	api.Audit.Record(r.Context(), newAuditEvent(r, domain.AuditDocumentCopy, userID, src.ID))
+	api.Audit.Record(r.Context(), newAuditEvent(r, domain.AuditDocumentCopy, userID, doc.ID))
+
+	w.Header().Set("ETag", documentETag(doc))
+	response.JSON(w, http.StatusCreated, toDocumentResponse(doc))
+}
+
+// transferDocumentHandler предлагает передать документ другому пользователю.
+// Вызывающий с правом document:override может передать любой документ сразу,
+// указав force.
+func (api *DocumentHandler) transferDocumentHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		response.Error(w, http.StatusBadRequest, "missing document id")
+		return
+	}
+
+	userID, ok := authorize(w, r, domain.PermDocumentWrite)
+	if !ok {
+		return
+	}
+
+	var req request.TransferDocumentRequest
+	if !decodeAndValidate(w, r, &req) {
+		return
+	}
+
+	doc, err := api.Service.GetDocumentByID(r.Context(), id)
+	if err != nil {
+		response.Error(w, http.StatusNotFound, "document not found")
+		return
+	}
+
+	if req.Force && !api.override(r, doc, userID) {
+		response.Error(w, http.StatusForbidden, "only an administrator can force a transfer")
+		return
+	}
+	if !req.Force && doc.OwnerID != userID {
+		response.Error(w, http.StatusForbidden, "access denied")
+		return
+	}
+
+	toUserID, err := api.Service.FindUserIDByLogin(r.Context(), req.Login)
+	if err != nil {
+		response.Error(w, http.StatusNotFound, "user not found")
+		return
+	}
+	if toUserID == doc.OwnerID {
+		response.Error(w, http.StatusBadRequest, "user already owns the document")
+		return
+	}
+
+	if req.Force {
+		updated, err := api.Service.ForceTransfer(r.Context(), doc.ID, toUserID, req.KeepAccess)
+		if err != nil {
+			writeTransferError(w, err)
+			return
+		}
+
+		event := newAuditEvent(r, domain.AuditDocumentTransfer, userID, doc.ID)
+		event.TargetID = toUserID
+		api.Audit.Record(r.Context(), event)
+
+		w.Header().Set("ETag", documentETag(updated))
+		response.JSON(w, http.StatusOK, toDocumentResponse(updated))
+		return
+	}
+
+	transfer, err := api.Service.OfferTransfer(r.Context(), &domain.DocumentTransfer{
+		DocumentID: doc.ID,
+		FromUserID: userID,
+		ToUserID:   toUserID,
+		KeepAccess: req.KeepAccess,
+	})
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "cannot offer transfer")
+		return
+	}
+
+	event := newAuditEvent(r, domain.AuditTransferOffer, userID, doc.ID)
+	event.TargetID = toUserID
+	api.Audit.Record(r.Context(), event)
+
+	writeTransfer(w, http.StatusAccepted, transfer)
+}
+
+func (api *DocumentHandler) getTransferHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		response.Error(w, http.StatusBadRequest, "missing document id")
+		return
+	}
+
+	userID, ok := authorize(w, r, domain.PermDocumentRead)
+	if !ok {
+		return
+	}
+
+	transfer, err := api.Service.GetTransfer(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			response.Error(w, http.StatusNotFound, "no pending transfer")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, "cannot get transfer")
+		return
+	}
+
+	if transfer.FromUserID != userID && transfer.ToUserID != userID {
+		response.Error(w, http.StatusNotFound, "no pending transfer")
+		return
+	}
+
+	writeTransfer(w, http.StatusOK, transfer)
+}
+
+func (api *DocumentHandler) acceptTransferHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		response.Error(w, http.StatusBadRequest, "missing document id")
+		return
+	}
+
+	userID, ok := authorize(w, r, domain.PermDocumentWrite)
+	if !ok {
+		return
+	}
+
+	doc, err := api.Service.AcceptTransfer(r.Context(), id, userID)
+	if err != nil {
+		writeTransferError(w, err)
+		return
+	}
+
+	event := newAuditEvent(r, domain.AuditDocumentTransfer, userID, doc.ID)
+	event.TargetID = userID
+	api.Audit.Record(r.Context(), event)
+
+	w.Header().Set("ETag", documentETag(doc))
+	response.JSON(w, http.StatusOK, toDocumentResponse(doc))
+}
+
+// cancelTransferHandler отзывает предложение отправителем или отклоняет его
+// получателем.
+func (api *DocumentHandler) cancelTransferHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		response.Error(w, http.StatusBadRequest, "missing document id")
+		return
+	}
+
+	userID, ok := authorize(w, r, domain.PermDocumentWrite)
+	if !ok {
+		return
+	}
+
+	if err := api.Service.CancelTransfer(r.Context(), id, userID); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			response.Error(w, http.StatusNotFound, "no pending transfer")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, "cannot cancel transfer")
+		return
+	}
+
+	api.Audit.Record(r.Context(), newAuditEvent(r, domain.AuditTransferCancel, userID, id))
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"response": map[string]bool{
+			id: true,
+		},
+	})
+}
+
+func (api *DocumentHandler) listTransfersHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authorize(w, r, domain.PermDocumentRead)
+	if !ok {
+		return
+	}
+
+	transfers, err := api.Service.ListIncomingTransfers(r.Context(), userID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "cannot list transfers")
+		return
+	}
+
+	resp := response.DocumentTransfersResponse{
+		Transfers: make([]response.DocumentTransfer, 0, len(transfers)),
+	}
+	for _, transfer := range transfers {
+		var item response.DocumentTransfer
+		if err := mapping.MapStruct(transfer, &item); err != nil {
+			response.Error(w, http.StatusInternalServerError, "cannot map transfer")
+			return
+		}
+		resp.Transfers = append(resp.Transfers, item)
+	}
+
+	response.JSON(w, http.StatusOK, resp)
+}
+
+func writeTransfer(w http.ResponseWriter, status int, transfer *domain.DocumentTransfer) {
+	var resp response.DocumentTransfer
+	if err := mapping.MapStruct(transfer, &resp); err != nil {
+		response.Error(w, http.StatusInternalServerError, "cannot map transfer")
+		return
+	}
+	response.JSON(w, status, resp)
+}
+
+func writeTransferError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		response.Error(w, http.StatusNotFound, "no pending transfer")
+	case errors.Is(err, domain.ErrQuotaExceeded):
+		response.Error(w, http.StatusRequestEntityTooLarge, err.Error())
+	default:
+		response.Error(w, http.StatusInternalServerError, "cannot transfer document")
+	}
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/DENFNC/web-test/internal/domain"
+	"github.com/DENFNC/web-test/internal/transport/dto/response"
+)
+
+// documentETag возвращает сильный ETag документа, который меняется вместе
+// с версией строки.
+func documentETag(doc *domain.Document) string {
+	return fmt.Sprintf(`"%s-%d"`, doc.ID, doc.Version)
+}
+
+// notModified сообщает, что у клиента уже есть текущая версия документа.
+// If-None-Match сравнивается слабо, поэтому префикс W/ игнорируется;
+// If-Modified-Since учитывается только без него.
+func notModified(r *http.Request, etag string, modtime time.Time) bool {
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+		if err != nil || modtime.IsZero() {
+			return false
+		}
+		return !modtime.Truncate(time.Second).After(since)
+	}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// ifMatchVersion проверяет If-Match и возвращает версию, которую хранилище
+// должно сверить при изменении, или 0, если заголовка нет. При несовпадении
+// отвечает 412 Precondition Failed.
+func ifMatchVersion(w http.ResponseWriter, r *http.Request, doc *domain.Document) (int64, bool) {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return 0, true
+	}
+
+	etag := documentETag(doc)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || tag == etag {
+			return doc.Version, true
+		}
+	}
+
+	w.Header().Set("ETag", etag)
+	response.Error(w, http.StatusPreconditionFailed, domain.ErrVersionMismatch.Error())
+	return 0, false
+}
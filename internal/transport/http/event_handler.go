@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/DENFNC/web-test/internal/domain"
+	"github.com/DENFNC/web-test/internal/service"
+	"github.com/DENFNC/web-test/internal/transport/dto/response"
+)
+
+type NotificationService interface {
+	Subscribe(userID string) *service.Subscription
+	Unsubscribe(sub *service.Subscription)
+	EventsSince(ctx context.Context, userID string, afterID int64) ([]*domain.UserEvent, error)
+}
+
+type EventHandler struct {
+	*slog.Logger
+	NotificationService
+	KeepAlive time.Duration
+}
+
+func NewEventHandler(
+	log *slog.Logger,
+	mux *http.ServeMux,
+	srv NotificationService,
+	keepAlive time.Duration,
+) {
+	handler := &EventHandler{
+		Logger:              log,
+		NotificationService: srv,
+		KeepAlive:           keepAlive,
+	}
+
+	mux.HandleFunc("GET /api/events", handler.streamHandler)
+}
+
+func (api *EventHandler) streamHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authorize(w, r, domain.PermDocumentRead)
+	if !ok {
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		response.Error(w, http.StatusInternalServerError, "streaming unsupported")
+		return
+	}
+
+	lastID, err := lastEventID(r)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid Last-Event-ID")
+		return
+	}
+
+	// Подписываемся до чтения пропущенных событий, чтобы не потерять те,
+	// что придут между запросом к базе и началом потока.
+	sub := api.NotificationService.Subscribe(userID)
+	defer api.NotificationService.Unsubscribe(sub)
+
+	var missed []*domain.UserEvent
+	if lastID > 0 {
+		missed, err = api.NotificationService.EventsSince(r.Context(), userID, lastID)
+		if err != nil {
+			response.Error(w, http.StatusInternalServerError, "cannot load missed events")
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprint(w, "retry: 3000\n\n")
+	for _, event := range missed {
+		if err := writeEvent(w, event); err != nil {
+			return
+		}
+		lastID = event.ID
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(api.KeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+		case event, ok := <-sub.Events:
+			if !ok {
+				return
+			}
+			if event.ID <= lastID {
+				continue
+			}
+			if err := writeEvent(w, event); err != nil {
+				return
+			}
+			lastID = event.ID
+		}
+		flusher.Flush()
+	}
+}
+
+func writeEvent(w http.ResponseWriter, event *domain.UserEvent) error {
+	data, err := json.Marshal(event.DocumentEvent)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
+
+// lastEventID читает заголовок Last-Event-ID, который браузер отправляет
+// при переподключении, или параметр last_event_id для первого подключения.
+func lastEventID(r *http.Request) (int64, error) {
+	v := r.Header.Get("Last-Event-ID")
+	if v == "" {
+		v = r.URL.Query().Get("last_event_id")
+	}
+	if v == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(v, 10, 64)
+}
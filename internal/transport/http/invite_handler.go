@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/DENFNC/web-test/internal/domain"
+	"github.com/DENFNC/web-test/internal/transport/dto/response"
+	"github.com/google/uuid"
+)
+
+type InviteService interface {
+	CreateInvite(ctx context.Context, adminID string) (*domain.Invite, error)
+	ListInvites(ctx context.Context) ([]domain.Invite, error)
+	RevokeInvite(ctx context.Context, id string) error
+}
+
+// InviteHandler управляет приглашениями на регистрацию; доступен ролям с
+// разрешением invite:manage.
+type InviteHandler struct {
+	*slog.Logger
+	InviteService
+}
+
+func NewInviteHandler(log *slog.Logger, mux *http.ServeMux, srv InviteService) {
+	handler := &InviteHandler{
+		Logger:        log,
+		InviteService: srv,
+	}
+
+	mux.HandleFunc("POST /api/admin/invites", handler.createInvite)
+	mux.HandleFunc("GET /api/admin/invites", handler.listInvites)
+	mux.HandleFunc("DELETE /api/admin/invites/{id}", handler.revokeInvite)
+}
+
+func (api *InviteHandler) createInvite(w http.ResponseWriter, r *http.Request) {
+	adminID, ok := authorize(w, r, domain.PermInviteManage)
+	if !ok {
+		return
+	}
+
+	invite, err := api.InviteService.CreateInvite(r.Context(), adminID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "cannot create invite")
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, toInviteResponse(*invite))
+}
+
+func (api *InviteHandler) listInvites(w http.ResponseWriter, r *http.Request) {
+	if _, ok := authorize(w, r, domain.PermInviteManage); !ok {
+		return
+	}
+
+	invites, err := api.InviteService.ListInvites(r.Context())
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "cannot list invites")
+		return
+	}
+
+	resp := response.InvitesResponse{Invites: make([]response.Invite, 0, len(invites))}
+	for _, invite := range invites {
+		resp.Invites = append(resp.Invites, toInviteResponse(invite))
+	}
+	response.JSON(w, http.StatusOK, resp)
+}
+
+// revokeInvite отзывает неиспользованное приглашение.
+func (api *InviteHandler) revokeInvite(w http.ResponseWriter, r *http.Request) {
+	if _, ok := authorize(w, r, domain.PermInviteManage); !ok {
+		return
+	}
+
+	id := r.PathValue("id")
+	if _, err := uuid.Parse(id); err != nil {
+		response.Error(w, http.StatusNotFound, "invite not found")
+		return
+	}
+
+	err := api.InviteService.RevokeInvite(r.Context(), id)
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		response.Error(w, http.StatusNotFound, "invite not found")
+		return
+	case err != nil:
+		response.Error(w, http.StatusInternalServerError, "cannot revoke invite")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"response": map[string]bool{
+			id: true,
+		},
+	})
+}
+
+func toInviteResponse(invite domain.Invite) response.Invite {
+	resp := response.Invite{
+		ID:        invite.ID,
+		Code:      invite.Code,
+		CreatedBy: invite.CreatedBy,
+		UsedBy:    invite.UsedBy,
+		ExpiresAt: invite.ExpiresAt,
+		CreatedAt: invite.CreatedAt,
+	}
+	if !invite.UsedAt.IsZero() {
+		usedAt := invite.UsedAt
+		resp.UsedAt = &usedAt
+	}
+	return resp
+}
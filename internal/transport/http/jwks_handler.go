@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"log/slog"
+	"math/big"
+	"net/http"
+
+	"github.com/DENFNC/web-test/internal/domain"
+	"github.com/DENFNC/web-test/internal/transport/dto/response"
+)
+
+type KeySet interface {
+	PublicKeys() []domain.PublicKey
+}
+
+type RevocationList interface {
+	RevokedTokens(ctx context.Context) ([]domain.RevokedToken, error)
+}
+
+type JWKSHandler struct {
+	*slog.Logger
+	Keys    KeySet
+	Revoked RevocationList
+}
+
+func NewJWKSHandler(log *slog.Logger, mux *http.ServeMux, keys KeySet, revoked RevocationList) {
+	handler := &JWKSHandler{
+		Logger:  log,
+		Keys:    keys,
+		Revoked: revoked,
+	}
+
+	mux.HandleFunc("GET /.well-known/jwks.json", handler.jwks)
+	mux.HandleFunc("GET /.well-known/revoked", handler.revoked)
+}
+
+func (api *JWKSHandler) jwks(w http.ResponseWriter, r *http.Request) {
+	resp := response.JWKS{Keys: []response.JWK{}}
+	for _, key := range api.Keys.PublicKeys() {
+		jwk := response.JWK{
+			KeyID:     key.ID,
+			Algorithm: key.Algorithm,
+			Use:       "sig",
+		}
+
+		switch pub := key.Key.(type) {
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		default:
+			continue
+		}
+
+		resp.Keys = append(resp.Keys, jwk)
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	response.JSON(w, http.StatusOK, resp)
+}
+
+// revoked отдаёт отозванные JWT, срок которых ещё не истёк. Сервисы,
+// проверяющие токены по подписи, периодически забирают список и отклоняют
+// токены с перечисленными jti или sid.
+func (api *JWKSHandler) revoked(w http.ResponseWriter, r *http.Request) {
+	if _, ok := authorize(w, r, domain.PermTokenRevokedRead); !ok {
+		return
+	}
+
+	tokens, err := api.Revoked.RevokedTokens(r.Context())
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "cannot list revoked tokens")
+		return
+	}
+
+	resp := response.RevokedTokens{
+		Tokens: make([]response.RevokedToken, 0, len(tokens)),
+	}
+	for _, token := range tokens {
+		resp.Tokens = append(resp.Tokens, response.RevokedToken{
+			ID:        token.ID,
+			SessionID: token.SessionID,
+			ExpiresAt: token.ExpiresAt,
+		})
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	response.JSON(w, http.StatusOK, resp)
+}
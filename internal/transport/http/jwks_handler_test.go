@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DENFNC/web-test/internal/domain"
+	"github.com/DENFNC/web-test/internal/transport/dto/response"
+)
+
+const testServiceKey = domain.APIKeyPrefix + "service"
+
+// serviceAccount — учётная запись с одним API-ключом testServiceKey.
+type serviceAccount struct {
+	role   string
+	scopes []string
+}
+
+func (a serviceAccount) ValidateToken(context.Context, string) (string, error) {
+	return "", domain.ErrInvalidToken
+}
+
+func (a serviceAccount) ValidateAPIKey(_ context.Context, key, _ string) (*domain.APIKey, error) {
+	if key != testServiceKey {
+		return nil, domain.ErrInvalidToken
+	}
+	return &domain.APIKey{ID: "key-1", UserID: "svc-1", Scopes: a.scopes}, nil
+}
+
+func (a serviceAccount) UserRole(context.Context, string) (string, error) {
+	return a.role, nil
+}
+
+type noKeys struct{}
+
+func (noKeys) PublicKeys() []domain.PublicKey { return nil }
+
+type revokedList []domain.RevokedToken
+
+func (l revokedList) RevokedTokens(context.Context) ([]domain.RevokedToken, error) {
+	return l, nil
+}
+
+func newRevokedTestServer(account serviceAccount) http.Handler {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	mux := http.NewServeMux()
+
+	NewJWKSHandler(log, mux, noKeys{}, revokedList{{
+		ID:        "jti-1",
+		SessionID: "sid-1",
+		ExpiresAt: time.Now().Add(time.Hour).UTC(),
+	}})
+	NewDocumentHandler(log, mux, nil, nil, 1<<20)
+
+	return Authenticate(account, account, account, time.Time{}, 1<<20)(mux)
+}
+
+func serve(h http.Handler, method, target string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	req.Header.Set("Authorization", "Bearer "+testServiceKey)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRevokedTokens_ServiceKey(t *testing.T) {
+	h := newRevokedTestServer(serviceAccount{
+		role:   domain.RoleService,
+		scopes: []string{domain.ScopeTokensRevoked},
+	})
+
+	rec := serve(h, http.MethodGet, "/.well-known/revoked")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /.well-known/revoked = %d %s, want 200", rec.Code, rec.Body)
+	}
+
+	var body struct {
+		Response response.RevokedTokens `json:"response"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if resp := body.Response; len(resp.Tokens) != 1 || resp.Tokens[0].ID != "jti-1" || resp.Tokens[0].SessionID != "sid-1" {
+		t.Errorf("revoked = %+v, want jti-1/sid-1", resp.Tokens)
+	}
+
+	for _, tt := range []struct{ method, target string }{
+		{http.MethodGet, "/api/docs"},
+		{http.MethodPost, "/api/docs"},
+		{http.MethodDelete, "/api/docs/doc-1"},
+	} {
+		if rec := serve(h, tt.method, tt.target); rec.Code != http.StatusForbidden {
+			t.Errorf("%s %s = %d, want 403", tt.method, tt.target, rec.Code)
+		}
+	}
+}
+
+func TestRevokedTokens_RequiresPermission(t *testing.T) {
+	tests := []struct {
+		name    string
+		account serviceAccount
+	}{
+		{
+			name:    "scope without role permission",
+			account: serviceAccount{role: domain.RoleUser, scopes: []string{domain.ScopeTokensRevoked}},
+		},
+		{
+			name:    "role without scope",
+			account: serviceAccount{role: domain.RoleService, scopes: []string{domain.ScopeDocsRead}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(newRevokedTestServer(tt.account), http.MethodGet, "/.well-known/revoked")
+			if rec.Code != http.StatusForbidden {
+				t.Errorf("GET /.well-known/revoked = %d, want 403", rec.Code)
+			}
+		})
+	}
+}
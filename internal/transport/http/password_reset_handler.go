@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/DENFNC/web-test/internal/domain"
+	"github.com/DENFNC/web-test/internal/transport/dto/request"
+	"github.com/DENFNC/web-test/internal/transport/dto/response"
+)
+
+// requestPasswordReset всегда отвечает 202, чтобы по ответу нельзя было
+// узнать, существует ли пользователь.
+func (api *AuthHandler) requestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	var req request.PasswordResetRequest
+	if !decodeAndValidate(w, r, &req) {
+		return
+	}
+
+	if err := api.AuthService.RequestPasswordReset(r.Context(), req.Login); err != nil {
+		response.Error(w, http.StatusInternalServerError, "cannot request password reset")
+		return
+	}
+
+	response.JSON(w, http.StatusAccepted, map[string]any{
+		"response": map[string]bool{
+			"sent": true,
+		},
+	})
+}
+
+func (api *AuthHandler) confirmPasswordReset(w http.ResponseWriter, r *http.Request) {
+	var req request.PasswordResetConfirmRequest
+	if !decodeAndValidate(w, r, &req) {
+		return
+	}
+
+	err := api.AuthService.ConfirmPasswordReset(r.Context(), req.Login, req.Code, req.NewPassword)
+	if retryLater(w, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, domain.ErrInvalidResetCode):
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	case err != nil:
+		response.Error(w, http.StatusInternalServerError, "cannot reset password")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"response": map[string]bool{
+			req.Login: true,
+		},
+	})
+}
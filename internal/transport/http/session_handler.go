@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/DENFNC/web-test/internal/domain"
+	"github.com/DENFNC/web-test/internal/transport/dto/response"
+	"github.com/google/uuid"
+)
+
+func (api *AuthHandler) listSessions(w http.ResponseWriter, r *http.Request) {
+	principal, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	sessions, err := api.AuthService.ListSessions(r.Context(), principal.UserID, principal.Token)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "cannot list sessions")
+		return
+	}
+
+	resp := response.SessionsResponse{Sessions: make([]response.Session, 0, len(sessions))}
+	for _, session := range sessions {
+		resp.Sessions = append(resp.Sessions, toSessionResponse(session))
+	}
+
+	response.JSON(w, http.StatusOK, resp)
+}
+
+func (api *AuthHandler) revokeSession(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	id := r.PathValue("id")
+	if _, err := uuid.Parse(id); err != nil {
+		response.Error(w, http.StatusNotFound, "session not found")
+		return
+	}
+
+	if err := api.AuthService.RevokeSession(r.Context(), userID, id); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			response.Error(w, http.StatusNotFound, "session not found")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, "cannot revoke session")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"response": map[string]bool{
+			id: true,
+		},
+	})
+}
+
+// revokeAllSessions завершает все сессии пользователя, включая текущую.
+func (api *AuthHandler) revokeAllSessions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	if err := api.AuthService.RevokeAllSessions(r.Context(), userID); err != nil {
+		response.Error(w, http.StatusInternalServerError, "cannot revoke sessions")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"response": map[string]bool{
+			userID: true,
+		},
+	})
+}
+
+func sessionInfo(r *http.Request) domain.SessionInfo {
+	return domain.SessionInfo{
+		IP:        clientIP(r),
+		UserAgent: r.UserAgent(),
+	}
+}
+
+func toSessionResponse(session domain.Session) response.Session {
+	resp := response.Session{
+		ID:        session.ID,
+		CreatedAt: session.CreatedAt,
+		IP:        session.IP,
+		UserAgent: session.UserAgent,
+		Current:   session.Current,
+	}
+	if !session.LastUsedAt.IsZero() {
+		lastUsedAt := session.LastUsedAt
+		resp.LastUsedAt = &lastUsedAt
+	}
+	return resp
+}
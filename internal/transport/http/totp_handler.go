@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/DENFNC/web-test/internal/domain"
+	"github.com/DENFNC/web-test/internal/transport/dto/request"
+	"github.com/DENFNC/web-test/internal/transport/dto/response"
+)
+
+// enrollTOTP выдаёт секрет для приложения-аутентификатора. Второй фактор
+// включается только после confirmTOTP.
+func (api *AuthHandler) enrollTOTP(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	enrollment, err := api.AuthService.EnrollTOTP(r.Context(), userID)
+	switch {
+	case errors.Is(err, domain.ErrTOTPEnabled):
+		response.Error(w, http.StatusConflict, err.Error())
+		return
+	case err != nil:
+		response.Error(w, http.StatusInternalServerError, "cannot enroll two-factor authentication")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.TOTPEnrollmentResponse{
+		Secret: enrollment.Secret,
+		URI:    enrollment.URI,
+	})
+}
+
+func (api *AuthHandler) confirmTOTP(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	var req request.ConfirmTOTPRequest
+	if !decodeAndValidate(w, r, &req) {
+		return
+	}
+
+	codes, err := api.AuthService.ConfirmTOTP(r.Context(), userID, req.Code)
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		response.Error(w, http.StatusNotFound, "two-factor enrollment not found")
+		return
+	case errors.Is(err, domain.ErrTOTPEnabled):
+		response.Error(w, http.StatusConflict, err.Error())
+		return
+	case errors.Is(err, domain.ErrInvalidOTP):
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	case err != nil:
+		response.Error(w, http.StatusInternalServerError, "cannot enable two-factor authentication")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+func (api *AuthHandler) disableTOTP(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	var req request.DisableTOTPRequest
+	if !decodeAndValidate(w, r, &req) {
+		return
+	}
+
+	err := api.AuthService.DisableTOTP(r.Context(), userID, req.Password)
+	if retryLater(w, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, domain.ErrInvalidCredentials):
+		response.Error(w, http.StatusForbidden, "invalid password")
+		return
+	case errors.Is(err, domain.ErrNotFound):
+		response.Error(w, http.StatusNotFound, "two-factor authentication is not enabled")
+		return
+	case err != nil:
+		response.Error(w, http.StatusInternalServerError, "cannot disable two-factor authentication")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"response": map[string]bool{
+			userID: true,
+		},
+	})
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/DENFNC/web-test/internal/domain"
+	"github.com/DENFNC/web-test/internal/transport/dto/request"
+	"github.com/DENFNC/web-test/internal/transport/dto/response"
+	"github.com/google/uuid"
+)
+
+type UserService interface {
+	SetUserRole(ctx context.Context, userID, role string) error
+}
+
+// UserHandler управляет ролями пользователей; доступен ролям с разрешением
+// role:manage.
+type UserHandler struct {
+	*slog.Logger
+	UserService
+	Audit AuditRecorder
+}
+
+func NewUserHandler(log *slog.Logger, mux *http.ServeMux, srv UserService, audit AuditRecorder) {
+	handler := &UserHandler{
+		Logger:      log,
+		UserService: srv,
+		Audit:       audit,
+	}
+
+	mux.HandleFunc("PUT /api/admin/users/{id}/role", handler.setRole)
+}
+
+// setRole не даёт сменить собственную роль, чтобы последний администратор
+// не лишил себя прав по ошибке.
+func (api *UserHandler) setRole(w http.ResponseWriter, r *http.Request) {
+	adminID, ok := authorize(w, r, domain.PermRoleManage)
+	if !ok {
+		return
+	}
+
+	id := r.PathValue("id")
+	if _, err := uuid.Parse(id); err != nil {
+		response.Error(w, http.StatusNotFound, "user not found")
+		return
+	}
+	if id == adminID {
+		response.Error(w, http.StatusConflict, "cannot change your own role")
+		return
+	}
+
+	var req request.SetUserRoleRequest
+	if !decodeAndValidate(w, r, &req) {
+		return
+	}
+
+	err := api.UserService.SetUserRole(r.Context(), id, req.Role)
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		response.Error(w, http.StatusNotFound, "user not found")
+		return
+	case errors.Is(err, domain.ErrInvalidRole):
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	case err != nil:
+		response.Error(w, http.StatusInternalServerError, "cannot set role")
+		return
+	}
+
+	event := newAuditEvent(r, domain.AuditRoleChange, adminID, "")
+	event.TargetID = id
+	api.Audit.Record(r.Context(), event)
+
+	response.JSON(w, http.StatusOK, response.UserRole{
+		UserID: id,
+		Role:   req.Role,
+	})
+}
@@ -0,0 +1,188 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/DENFNC/web-test/internal/domain"
+	"github.com/DENFNC/web-test/internal/transport/dto/request"
+	"github.com/DENFNC/web-test/internal/transport/dto/response"
+)
+
+type WebhookService interface {
+	CreateWebhook(ctx context.Context, ownerID, url string, events []string) (*domain.Webhook, error)
+	ListWebhooks(ctx context.Context, ownerID string) ([]*domain.Webhook, error)
+	DeleteWebhook(ctx context.Context, id, ownerID string) error
+	ListDeliveries(ctx context.Context, id, ownerID string, limit, offset uint) ([]*domain.WebhookDelivery, error)
+}
+
+type WebhookHandler struct {
+	*slog.Logger
+	WebhookService
+}
+
+func NewWebhookHandler(log *slog.Logger, mux *http.ServeMux, srv WebhookService) {
+	handler := &WebhookHandler{
+		Logger:         log,
+		WebhookService: srv,
+	}
+
+	mux.HandleFunc("POST /api/webhooks", handler.createWebhookHandler)
+	mux.HandleFunc("GET /api/webhooks", handler.getWebhooksHandler)
+	mux.HandleFunc("DELETE /api/webhooks/{id}", handler.deleteWebhookHandler)
+	mux.HandleFunc("GET /api/webhooks/{id}/deliveries", handler.getDeliveriesHandler)
+}
+
+func (api *WebhookHandler) createWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authorize(w, r, domain.PermWebhookManage)
+	if !ok {
+		return
+	}
+
+	var req request.CreateWebhookRequest
+	if !decodeAndValidate(w, r, &req) {
+		return
+	}
+
+	hook, err := api.WebhookService.CreateWebhook(r.Context(), userID, req.URL, req.Events)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "cannot create webhook")
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, response.CreateWebhookResponse{
+		Webhook: toWebhookResponse(hook),
+		Secret:  hook.Secret,
+	})
+}
+
+func (api *WebhookHandler) getWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authorize(w, r, domain.PermWebhookManage)
+	if !ok {
+		return
+	}
+
+	hooks, err := api.WebhookService.ListWebhooks(r.Context(), userID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "cannot list webhooks")
+		return
+	}
+
+	resp := response.WebhooksResponse{
+		Webhooks: make([]response.Webhook, 0, len(hooks)),
+	}
+	for _, hook := range hooks {
+		resp.Webhooks = append(resp.Webhooks, toWebhookResponse(hook))
+	}
+
+	response.JSON(w, http.StatusOK, resp)
+}
+
+func (api *WebhookHandler) deleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	userID, ok := authorize(w, r, domain.PermWebhookManage)
+	if !ok {
+		return
+	}
+
+	if err := api.WebhookService.DeleteWebhook(r.Context(), id, userID); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			response.Error(w, http.StatusNotFound, "webhook not found")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, "cannot delete webhook")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"response": map[string]bool{
+			id: true,
+		},
+	})
+}
+
+func (api *WebhookHandler) getDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	userID, ok := authorize(w, r, domain.PermWebhookManage)
+	if !ok {
+		return
+	}
+
+	limit, offset, ok := parsePage(w, r)
+	if !ok {
+		return
+	}
+
+	deliveries, err := api.WebhookService.ListDeliveries(r.Context(), id, userID, limit, offset)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			response.Error(w, http.StatusNotFound, "webhook not found")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, "cannot list deliveries")
+		return
+	}
+
+	resp := response.WebhookDeliveriesResponse{
+		Deliveries: make([]response.WebhookDelivery, 0, len(deliveries)),
+	}
+	for _, d := range deliveries {
+		item := response.WebhookDelivery{
+			ID:             d.ID,
+			EventType:      d.EventType,
+			Payload:        d.Payload,
+			Status:         d.Status,
+			Attempts:       d.Attempts,
+			NextAttemptAt:  d.NextAttemptAt,
+			LastStatusCode: d.LastStatusCode,
+			LastError:      d.LastError,
+			CreatedAt:      d.CreatedAt,
+		}
+		if !d.DeliveredAt.IsZero() {
+			item.DeliveredAt = &d.DeliveredAt
+		}
+		resp.Deliveries = append(resp.Deliveries, item)
+	}
+
+	response.JSON(w, http.StatusOK, resp)
+}
+
+func toWebhookResponse(hook *domain.Webhook) response.Webhook {
+	return response.Webhook{
+		ID:        hook.ID,
+		URL:       hook.URL,
+		Events:    hook.Events,
+		IsActive:  hook.IsActive,
+		CreatedAt: hook.CreatedAt,
+	}
+}
+
+// parsePage разбирает limit и offset; пустые значения означают значения по
+// умолчанию.
+func parsePage(w http.ResponseWriter, r *http.Request) (limit, offset uint, ok bool) {
+	query := r.URL.Query()
+
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid limit")
+			return 0, 0, false
+		}
+		limit = uint(n)
+	}
+	if v := query.Get("offset"); v != "" {
+		n, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid offset")
+			return 0, 0, false
+		}
+		offset = uint(n)
+	}
+
+	return limit, offset, true
+}
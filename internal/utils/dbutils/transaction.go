@@ -9,6 +9,13 @@ import (
 
 type TxFunc func(tx pgx.Tx) error
 
+// Querier реализуют и пул, и транзакция, что позволяет использовать один
+// запрос в обоих контекстах.
+type Querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
 func WithTransaction(ctx context.Context, db *pgxpool.Pool, fn TxFunc) (err error) {
 	tx, err := db.BeginTx(ctx, pgx.TxOptions{})
 	if err != nil {
@@ -49,6 +49,10 @@ func MapStructModel(src, dst any) error {
 			val := srcValue.Interface().(time.Time)
 			dstValue.Set(reflect.ValueOf(toPgTimestamp(val)))
 
+		case reflect.TypeOf(pgtype.Timestamptz{}):
+			val := srcValue.Interface().(time.Time)
+			dstValue.Set(reflect.ValueOf(toPgTimestamp(val)))
+
 		case reflect.TypeOf(pgtype.Bool{}):
 			val := srcValue.Interface().(bool)
 			dstValue.Set(reflect.ValueOf(toPgBool(val)))
@@ -102,14 +106,6 @@ func MapStructModelToDomain(src, dst any) error {
 			continue
 		}
 
-		if srcField.Type.Kind() == reflect.Struct && dstField.Type.Kind() == reflect.Struct {
-			err := MapStructModelToDomain(srcValue.Addr().Interface(), dstValue.Addr().Interface())
-			if err != nil {
-				return err
-			}
-			continue
-		}
-
 		switch srcField.Type {
 		case reflect.TypeOf(pgtype.Text{}):
 			text := srcValue.Interface().(pgtype.Text)
@@ -142,6 +138,14 @@ func MapStructModelToDomain(src, dst any) error {
 		default:
 			if srcValue.Type() == dstValue.Type() {
 				dstValue.Set(srcValue)
+				continue
+			}
+
+			if srcField.Type.Kind() == reflect.Struct && dstField.Type.Kind() == reflect.Struct {
+				err := MapStructModelToDomain(srcValue.Addr().Interface(), dstValue.Addr().Interface())
+				if err != nil {
+					return err
+				}
 			}
 		}
 	}
@@ -0,0 +1,76 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// BatchFunc обрабатывает не больше limit элементов и возвращает, сколько
+// было обработано.
+type BatchFunc func(ctx context.Context, limit uint) (int, error)
+
+// BatchWorker периодически вызывает BatchFunc и повторяет вызов, пока
+// пачки приходят полными, чтобы разобрать накопившуюся очередь за один тик.
+type BatchWorker struct {
+	*slog.Logger
+	name      string
+	fn        BatchFunc
+	interval  time.Duration
+	batchSize uint
+}
+
+func NewBatchWorker(
+	log *slog.Logger,
+	name string,
+	fn BatchFunc,
+	interval time.Duration,
+	batchSize uint,
+) *BatchWorker {
+	return &BatchWorker{
+		Logger:    log,
+		name:      name,
+		fn:        fn,
+		interval:  interval,
+		batchSize: batchSize,
+	}
+}
+
+func (w *BatchWorker) Run(ctx context.Context) {
+	const op = "worker.BatchWorker.Run"
+
+	log := w.Logger.With("op", op, "worker", w.name)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for {
+			n, err := w.fn(ctx, w.batchSize)
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Error(
+						"Batch processing failed",
+						slog.String("err", err.Error()),
+					)
+				}
+				break
+			}
+			if n > 0 {
+				log.Info(
+					"Batch processed",
+					slog.Int("count", n),
+				)
+			}
+			if uint(n) < w.batchSize {
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS user_usage;
+
+ALTER TABLE documents
+DROP COLUMN IF EXISTS size_bytes;
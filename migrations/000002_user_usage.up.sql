@@ -0,0 +1,24 @@
+ALTER TABLE documents
+ADD COLUMN IF NOT EXISTS size_bytes BIGINT NOT NULL DEFAULT 0 CHECK (size_bytes >= 0);
+
+CREATE TABLE IF NOT EXISTS
+    user_usage (
+        user_id UUID PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+        bytes_used BIGINT NOT NULL DEFAULT 0 CHECK (bytes_used >= 0),
+        documents_count BIGINT NOT NULL DEFAULT 0 CHECK (documents_count >= 0),
+        quota_bytes BIGINT CHECK (quota_bytes >= 0),
+        quota_documents BIGINT CHECK (quota_documents >= 0),
+        updated_at TIMESTAMPTZ DEFAULT NOW()
+    );
+
+INSERT INTO
+    user_usage (user_id, bytes_used, documents_count)
+SELECT
+    owner_id,
+    SUM(size_bytes),
+    COUNT(*)
+FROM
+    documents
+GROUP BY
+    owner_id
+ON CONFLICT (user_id) DO NOTHING;
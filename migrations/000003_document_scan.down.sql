@@ -0,0 +1,4 @@
+ALTER TABLE documents
+DROP COLUMN IF EXISTS quarantined,
+DROP COLUMN IF EXISTS scan_signature,
+DROP COLUMN IF EXISTS scan_status;
@@ -0,0 +1,6 @@
+ALTER TABLE documents
+ADD COLUMN IF NOT EXISTS scan_status TEXT NOT NULL DEFAULT 'unscanned' CHECK (
+    scan_status IN ('unscanned', 'clean', 'infected', 'failed')
+),
+ADD COLUMN IF NOT EXISTS scan_signature TEXT,
+ADD COLUMN IF NOT EXISTS quarantined BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS documents_expires_at_idx;
+
+ALTER TABLE documents
+DROP COLUMN IF EXISTS expires_at;
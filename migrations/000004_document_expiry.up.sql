@@ -0,0 +1,6 @@
+ALTER TABLE documents
+ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS documents_expires_at_idx ON documents (expires_at)
+WHERE
+    expires_at IS NOT NULL;
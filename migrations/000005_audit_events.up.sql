@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS
+    audit_events (
+        id BIGSERIAL PRIMARY KEY,
+        actor_id UUID,
+        document_id UUID,
+        target_id UUID,
+        action TEXT NOT NULL CHECK (LENGTH(action) > 0),
+        ip TEXT,
+        user_agent TEXT,
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+    );
+
+CREATE INDEX IF NOT EXISTS audit_events_document_idx ON audit_events (document_id, created_at);
+
+CREATE INDEX IF NOT EXISTS audit_events_actor_idx ON audit_events (actor_id, created_at);
+
+CREATE INDEX IF NOT EXISTS audit_events_created_at_idx ON audit_events (created_at);
+
+-- Журнал только дополняется: события переживают удаление документов и
+-- пользователей, поэтому внешних ключей нет, а изменение строк запрещено.
+CREATE OR REPLACE FUNCTION audit_events_append_only () RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_events_append_only BEFORE
+UPDATE
+OR DELETE ON audit_events FOR EACH ROW
+EXECUTE FUNCTION audit_events_append_only ();
@@ -0,0 +1,37 @@
+CREATE TABLE IF NOT EXISTS
+    webhooks (
+        id UUID PRIMARY KEY,
+        owner_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+        url TEXT NOT NULL CHECK (url ~ '^https?://'),
+        secret TEXT NOT NULL,
+        is_active BOOLEAN NOT NULL DEFAULT TRUE,
+        created_at TIMESTAMPTZ DEFAULT NOW()
+    );
+
+CREATE TABLE IF NOT EXISTS
+    webhook_subscriptions (
+        webhook_id UUID NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+        event_type TEXT NOT NULL,
+        PRIMARY KEY (webhook_id, event_type)
+    );
+
+CREATE TABLE IF NOT EXISTS
+    webhook_deliveries (
+        id BIGSERIAL PRIMARY KEY,
+        webhook_id UUID NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+        event_type TEXT NOT NULL,
+        payload JSONB NOT NULL,
+        status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'succeeded', 'failed')),
+        attempts INTEGER NOT NULL DEFAULT 0,
+        next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+        last_status_code INTEGER,
+        last_error TEXT,
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+        delivered_at TIMESTAMPTZ
+    );
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_due_idx ON webhook_deliveries (next_attempt_at)
+WHERE
+    status = 'pending';
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_webhook_idx ON webhook_deliveries (webhook_id, created_at);
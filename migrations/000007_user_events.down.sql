@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS user_events;
+
+DROP FUNCTION IF EXISTS notify_user_event ();
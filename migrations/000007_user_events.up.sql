@@ -0,0 +1,35 @@
+CREATE TABLE IF NOT EXISTS
+    user_events (
+        id BIGSERIAL PRIMARY KEY,
+        recipient_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+        event_type TEXT NOT NULL,
+        document_id UUID NOT NULL,
+        owner_id UUID NOT NULL,
+        user_id UUID,
+        occurred_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+    );
+
+CREATE INDEX IF NOT EXISTS user_events_recipient_idx ON user_events (recipient_id, id);
+
+CREATE INDEX IF NOT EXISTS user_events_occurred_at_idx ON user_events (occurred_at);
+
+-- Каждое событие рассылается всем экземплярам приложения через NOTIFY,
+-- каждый экземпляр доставляет его своим SSE-подписчикам.
+CREATE OR REPLACE FUNCTION notify_user_event () RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_notify('user_events', json_build_object(
+        'id', NEW.id,
+        'recipient_id', NEW.recipient_id,
+        'type', NEW.event_type,
+        'document_id', NEW.document_id,
+        'owner_id', NEW.owner_id,
+        'user_id', NEW.user_id,
+        'occurred_at', NEW.occurred_at
+    )::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER user_events_notify
+AFTER INSERT ON user_events FOR EACH ROW
+EXECUTE FUNCTION notify_user_event ();
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS
+    document_locks (
+        document_id UUID PRIMARY KEY REFERENCES documents (id) ON DELETE CASCADE,
+        user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+        acquired_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+        expires_at TIMESTAMPTZ NOT NULL
+    );
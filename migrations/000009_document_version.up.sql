@@ -0,0 +1,4 @@
+ALTER TABLE documents
+ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
+
+CREATE INDEX IF NOT EXISTS documents_owner_created_idx ON documents (owner_id, created_at DESC);
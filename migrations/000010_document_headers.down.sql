@@ -0,0 +1,3 @@
+ALTER TABLE documents
+DROP COLUMN IF EXISTS checksum_sha256,
+DROP COLUMN IF EXISTS updated_at;
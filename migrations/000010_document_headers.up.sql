@@ -0,0 +1,13 @@
+ALTER TABLE documents
+ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ,
+ADD COLUMN IF NOT EXISTS checksum_sha256 TEXT;
+
+UPDATE documents
+SET
+    updated_at = COALESCE(created_at, NOW())
+WHERE
+    updated_at IS NULL;
+
+ALTER TABLE documents
+ALTER COLUMN updated_at SET DEFAULT NOW(),
+ALTER COLUMN updated_at SET NOT NULL;
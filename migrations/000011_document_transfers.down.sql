@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS documents_file_name_idx;
+
+DROP TABLE IF EXISTS document_transfers;
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS
+    document_transfers (
+        document_id UUID PRIMARY KEY REFERENCES documents (id) ON DELETE CASCADE,
+        from_user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+        to_user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+        keep_access BOOLEAN NOT NULL DEFAULT FALSE,
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+    );
+
+CREATE INDEX IF NOT EXISTS document_transfers_to_user_idx ON document_transfers (to_user_id);
+
+CREATE INDEX IF NOT EXISTS documents_file_name_idx ON documents (file_name);
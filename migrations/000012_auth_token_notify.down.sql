@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS auth_tokens_deleted_notify ON auth_tokens;
+
+DROP TRIGGER IF EXISTS auth_tokens_revoked_notify ON auth_tokens;
+
+DROP FUNCTION IF EXISTS notify_auth_token_revoked ();
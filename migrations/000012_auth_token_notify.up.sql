@@ -0,0 +1,18 @@
+-- Об отзыве или удалении токена сообщается всем экземплярам приложения,
+-- чтобы они сбросили локальный кэш. В уведомлении передаётся SHA-256
+-- токена, а не сам токен.
+CREATE OR REPLACE FUNCTION notify_auth_token_revoked () RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_notify('auth_tokens_revoked', encode(sha256(convert_to(OLD.token, 'UTF8')), 'hex'));
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER auth_tokens_revoked_notify
+AFTER UPDATE OF is_revoked ON auth_tokens FOR EACH ROW
+WHEN (NEW.is_revoked AND NOT OLD.is_revoked)
+EXECUTE FUNCTION notify_auth_token_revoked ();
+
+CREATE TRIGGER auth_tokens_deleted_notify
+AFTER DELETE ON auth_tokens FOR EACH ROW
+EXECUTE FUNCTION notify_auth_token_revoked ();
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS refresh_tokens;
+
+DROP INDEX IF EXISTS auth_tokens_expires_at_idx;
+
+DROP INDEX IF EXISTS auth_tokens_family_idx;
+
+ALTER TABLE auth_tokens
+DROP COLUMN IF EXISTS family_id,
+DROP COLUMN IF EXISTS expires_at;
@@ -0,0 +1,36 @@
+ALTER TABLE auth_tokens
+ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ,
+ADD COLUMN IF NOT EXISTS family_id UUID;
+
+-- Бессрочные токены, выданные до появления срока действия, получают сутки,
+-- чтобы клиенты успели перейти на refresh-токены.
+UPDATE auth_tokens
+SET
+    expires_at = NOW() + INTERVAL '1 day'
+WHERE
+    expires_at IS NULL;
+
+ALTER TABLE auth_tokens
+ALTER COLUMN expires_at SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS auth_tokens_family_idx ON auth_tokens (family_id);
+
+CREATE INDEX IF NOT EXISTS auth_tokens_expires_at_idx ON auth_tokens (expires_at);
+
+-- Refresh-токены хранятся только в виде SHA-256. Все токены, выданные при
+-- одном входе, образуют семейство: повторное предъявление уже обменянного
+-- refresh-токена отзывает всё семейство.
+CREATE TABLE IF NOT EXISTS
+    refresh_tokens (
+        token_hash TEXT PRIMARY KEY,
+        user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+        family_id UUID NOT NULL,
+        expires_at TIMESTAMPTZ NOT NULL,
+        used_at TIMESTAMPTZ,
+        is_revoked BOOLEAN NOT NULL DEFAULT FALSE,
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+    );
+
+CREATE INDEX IF NOT EXISTS refresh_tokens_family_idx ON refresh_tokens (family_id);
+
+CREATE INDEX IF NOT EXISTS refresh_tokens_expires_at_idx ON refresh_tokens (expires_at);
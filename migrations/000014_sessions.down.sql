@@ -0,0 +1,17 @@
+DROP TRIGGER IF EXISTS auth_tokens_revoked_notify ON auth_tokens;
+
+CREATE TRIGGER auth_tokens_revoked_notify
+AFTER UPDATE OF is_revoked ON auth_tokens FOR EACH ROW
+WHEN (NEW.is_revoked AND NOT OLD.is_revoked)
+EXECUTE FUNCTION notify_auth_token_revoked ();
+
+DROP INDEX IF EXISTS auth_tokens_user_id_idx;
+
+DROP INDEX IF EXISTS auth_tokens_family_key;
+
+CREATE INDEX IF NOT EXISTS auth_tokens_family_idx ON auth_tokens (family_id);
+
+ALTER TABLE auth_tokens
+DROP COLUMN IF EXISTS user_agent,
+DROP COLUMN IF EXISTS ip,
+DROP COLUMN IF EXISTS last_used_at;
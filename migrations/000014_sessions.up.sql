@@ -0,0 +1,47 @@
+-- Сессия — семейство токенов одного входа. Она хранится одной строкой
+-- auth_tokens: при обновлении токенов строка перезаписывается, сохраняя
+-- время начала сессии, адрес и клиента.
+ALTER TABLE auth_tokens
+ADD COLUMN IF NOT EXISTS last_used_at TIMESTAMPTZ,
+ADD COLUMN IF NOT EXISTS ip TEXT,
+ADD COLUMN IF NOT EXISTS user_agent TEXT;
+
+UPDATE auth_tokens t
+SET
+    created_at = f.started_at
+FROM
+    (
+        SELECT
+            family_id,
+            MIN(created_at) AS started_at
+        FROM
+            auth_tokens
+        WHERE
+            family_id IS NOT NULL
+        GROUP BY
+            family_id
+    ) f
+WHERE
+    t.family_id = f.family_id;
+
+DELETE FROM auth_tokens a USING auth_tokens b
+WHERE
+    a.family_id = b.family_id
+    AND (a.expires_at, a.ctid) < (b.expires_at, b.ctid);
+
+DROP INDEX IF EXISTS auth_tokens_family_idx;
+
+CREATE UNIQUE INDEX IF NOT EXISTS auth_tokens_family_key ON auth_tokens (family_id);
+
+CREATE INDEX IF NOT EXISTS auth_tokens_user_id_idx ON auth_tokens (user_id);
+
+-- Замена токена при обновлении тоже делает прежний токен недействительным.
+DROP TRIGGER IF EXISTS auth_tokens_revoked_notify ON auth_tokens;
+
+CREATE TRIGGER auth_tokens_revoked_notify
+AFTER UPDATE OF is_revoked, token ON auth_tokens FOR EACH ROW
+WHEN (
+    (NEW.is_revoked AND NOT OLD.is_revoked)
+    OR NEW.token IS DISTINCT FROM OLD.token
+)
+EXECUTE FUNCTION notify_auth_token_revoked ();
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS password_resets;
+
+DROP INDEX IF EXISTS users_email_key;
+
+ALTER TABLE users
+DROP COLUMN IF EXISTS email;
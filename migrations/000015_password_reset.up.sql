@@ -0,0 +1,16 @@
+-- Адрес для восстановления пароля необязателен.
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS email TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_key ON users (LOWER(email));
+
+-- У пользователя не больше одного действующего кода; новый запрос заменяет
+-- прежний. Хранится только SHA-256 кода.
+CREATE TABLE IF NOT EXISTS
+    password_resets (
+        user_id UUID PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+        code_hash TEXT NOT NULL,
+        attempts INTEGER NOT NULL DEFAULT 0,
+        expires_at TIMESTAMPTZ NOT NULL,
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+    );
@@ -0,0 +1,21 @@
+-- Второй фактор по TOTP. Пока enabled_at пуст, секрет выдан, но не
+-- подтверждён и при входе не проверяется. last_step — шаг последнего
+-- принятого кода, чтобы один код нельзя было использовать дважды.
+CREATE TABLE IF NOT EXISTS
+    user_totp (
+        user_id UUID PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+        secret TEXT NOT NULL,
+        last_step BIGINT NOT NULL DEFAULT 0,
+        enabled_at TIMESTAMPTZ,
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+    );
+
+-- Резервные коды одноразовые; хранится только SHA-256.
+CREATE TABLE IF NOT EXISTS
+    totp_recovery_codes (
+        user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+        code_hash TEXT NOT NULL,
+        used_at TIMESTAMPTZ,
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+        PRIMARY KEY (user_id, code_hash)
+    );
@@ -0,0 +1,12 @@
+-- Счётчики неудачных входов. key — логин или адрес клиента с префиксом
+-- (login:..., ip:...); строка не учитывается и удаляется после expires_at.
+CREATE TABLE IF NOT EXISTS
+    login_attempts (
+        key TEXT PRIMARY KEY,
+        failures INTEGER NOT NULL DEFAULT 0,
+        last_failure_at TIMESTAMPTZ,
+        locked_until TIMESTAMPTZ,
+        expires_at TIMESTAMPTZ NOT NULL
+    );
+
+CREATE INDEX IF NOT EXISTS login_attempts_expires_at_idx ON login_attempts (expires_at);
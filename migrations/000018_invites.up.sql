@@ -0,0 +1,12 @@
+-- Одноразовые приглашения на регистрацию. Хранится только SHA-256 кода;
+-- использованные приглашения остаются как история.
+CREATE TABLE IF NOT EXISTS
+    invites (
+        id UUID PRIMARY KEY,
+        code_hash TEXT UNIQUE NOT NULL,
+        created_by UUID REFERENCES users (id) ON DELETE SET NULL,
+        used_by UUID REFERENCES users (id) ON DELETE SET NULL,
+        used_at TIMESTAMPTZ,
+        expires_at TIMESTAMPTZ NOT NULL,
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+    );
@@ -0,0 +1,5 @@
+-- Роль определяет набор разрешений пользователя, см. domain.HasPermission.
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'user' CONSTRAINT users_role_check CHECK (
+    role IN ('admin', 'user', 'read-only')
+);
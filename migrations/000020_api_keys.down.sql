@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS api_key_networks;
+
+DROP TABLE IF EXISTS api_key_scopes;
+
+DROP TABLE IF EXISTS api_keys;
@@ -0,0 +1,31 @@
+-- API-ключи пользователей и сервисных учётных записей. Хранится только
+-- SHA-256 ключа; prefix — его начало для отображения в списке.
+CREATE TABLE IF NOT EXISTS
+    api_keys (
+        id UUID PRIMARY KEY,
+        user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+        name TEXT NOT NULL,
+        key_hash TEXT UNIQUE NOT NULL,
+        prefix TEXT NOT NULL,
+        expires_at TIMESTAMPTZ,
+        last_used_at TIMESTAMPTZ,
+        last_used_ip TEXT,
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+    );
+
+CREATE INDEX IF NOT EXISTS api_keys_user_id_idx ON api_keys (user_id);
+
+CREATE TABLE IF NOT EXISTS
+    api_key_scopes (
+        api_key_id UUID NOT NULL REFERENCES api_keys (id) ON DELETE CASCADE,
+        scope TEXT NOT NULL,
+        PRIMARY KEY (api_key_id, scope)
+    );
+
+-- Сети, из которых принимается ключ; без записей — из любых.
+CREATE TABLE IF NOT EXISTS
+    api_key_networks (
+        api_key_id UUID NOT NULL REFERENCES api_keys (id) ON DELETE CASCADE,
+        network TEXT NOT NULL,
+        PRIMARY KEY (api_key_id, network)
+    );
@@ -0,0 +1,12 @@
+-- Отозванные access-токены в формате JWT. Список публикуется для сервисов,
+-- которые проверяют токены только по подписи; строки удаляются после
+-- истечения токена.
+CREATE TABLE IF NOT EXISTS
+    revoked_tokens (
+        jti TEXT PRIMARY KEY,
+        session_id UUID,
+        expires_at TIMESTAMPTZ NOT NULL,
+        revoked_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+    );
+
+CREATE INDEX IF NOT EXISTS revoked_tokens_expires_at_idx ON revoked_tokens (expires_at);
@@ -0,0 +1,9 @@
+UPDATE users
+SET
+    role = 'read-only'
+WHERE
+    role = 'service';
+
+ALTER TABLE users
+DROP CONSTRAINT IF EXISTS users_role_check,
+ADD CONSTRAINT users_role_check CHECK (role IN ('admin', 'user', 'read-only'));
@@ -0,0 +1,6 @@
+-- Роль service для сервисных учётных записей других систем.
+ALTER TABLE users
+DROP CONSTRAINT IF EXISTS users_role_check,
+ADD CONSTRAINT users_role_check CHECK (
+    role IN ('admin', 'user', 'read-only', 'service')
+);